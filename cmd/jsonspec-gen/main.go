@@ -0,0 +1,63 @@
+// Command jsonspec-gen generates Go types from a spec stored as a JSON file.
+//
+// Usage:
+//
+//	jsonspec-gen [-pkg name] [-type Name] [-o types.go] spec.json
+//
+// The file contains one spec, as marshaled by encoding/json. The generated types have struct tags
+// so that jsonspec.For returns the same spec for them. The type is named after the file in
+// PascalCase unless -type is given, so intercom_args.json declares IntercomArgs. The source is
+// written to standard output unless -o is given.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/birdie-ai/jsonspec"
+	"github.com/birdie-ai/jsonspec/internal/cmdutil"
+)
+
+func main() {
+	pkg := flag.String("pkg", "main", "name of the package of the generated source")
+	name := flag.String("type", "", "name of the generated type, instead of the name of the file")
+	output := flag.String("o", "", "write the source to this file instead of standard output")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: jsonspec-gen [-pkg name] [-type Name] [-o types.go] spec.json\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), *pkg, *name, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "jsonspec-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(path, pkg, name, output string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	spec := new(jsonspec.Spec)
+	if err := json.Unmarshal(data, spec); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if name == "" {
+		name = cmdutil.TypeName(path)
+	}
+	src, err := jsonspec.GoSource(pkg, name, spec)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(output, src, 0o644)
+}
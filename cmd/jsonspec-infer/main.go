@@ -0,0 +1,66 @@
+// Command jsonspec-infer infers a spec from sample JSON documents.
+//
+// Usage:
+//
+//	jsonspec-infer [-o spec.json] sample.json...
+//
+// Each file contains one or more JSON documents, like a JSON Lines file. The spec accepting all of
+// them is written to standard output as JSON unless -o is given. Samples are read from standard
+// input if no files are given.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/birdie-ai/jsonspec"
+)
+
+func main() {
+	output := flag.String("o", "", "write the spec to this file instead of standard output")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: jsonspec-infer [-o spec.json] sample.json...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if err := run(flag.Args(), *output); err != nil {
+		fmt.Fprintf(os.Stderr, "jsonspec-infer: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(paths []string, output string) error {
+	var samples bytes.Buffer
+	if len(paths) == 0 {
+		if _, err := io.Copy(&samples, os.Stdin); err != nil {
+			return err
+		}
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		samples.Write(data)
+		// documents in different files must not run together
+		samples.WriteByte('\n')
+	}
+	spec, err := jsonspec.InferJSON(samples.Bytes())
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(output, data, 0o644)
+}
@@ -0,0 +1,65 @@
+// Command jsonspec-ts renders specs stored as JSON files into one file of TypeScript declarations.
+//
+// Usage:
+//
+//	jsonspec-ts [-o types.d.ts] spec.json...
+//
+// Each file contains one spec, as marshaled by encoding/json. The type declared for it is named
+// after the file in PascalCase, so intercom_args.json declares IntercomArgs. The declarations are
+// written to standard output unless -o is given.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/birdie-ai/jsonspec"
+	"github.com/birdie-ai/jsonspec/internal/cmdutil"
+)
+
+func main() {
+	output := flag.String("o", "", "write the declarations to this file instead of standard output")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: jsonspec-ts [-o types.d.ts] spec.json...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Args(), *output); err != nil {
+		fmt.Fprintf(os.Stderr, "jsonspec-ts: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(paths []string, output string) error {
+	specs := make(map[string]*jsonspec.Spec, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		spec := new(jsonspec.Spec)
+		if err := json.Unmarshal(data, spec); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		name := cmdutil.TypeName(path)
+		if _, ok := specs[name]; ok {
+			return fmt.Errorf("%s: duplicate type name %s", path, name)
+		}
+		specs[name] = spec
+	}
+	declarations, err := jsonspec.TypeScript(specs)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.WriteString(declarations)
+		return err
+	}
+	return os.WriteFile(output, []byte(declarations), 0o644)
+}
@@ -0,0 +1,268 @@
+package jsonspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// A Difference is a difference between two versions of a spec, found by [Compare].
+type Difference struct {
+	// Path is the JSON Pointer (RFC 6901) to the field that differs, where "*" stands for any
+	// element of an array or any value of an object. It's empty for the root.
+	Path string
+
+	// Breaking is true if values accepted by the old spec may be rejected by the new one.
+	Breaking bool
+
+	// Message describes the difference, for example "type changed from string to integer".
+	Message string
+}
+
+// String returns the difference formatted as "path: message", followed by " (breaking)" for
+// breaking differences.
+func (d Difference) String() string {
+	s := d.Message
+	if d.Path != "" {
+		s = d.Path + ": " + s
+	}
+	if d.Breaking {
+		s += " (breaking)"
+	}
+	return s
+}
+
+// Compare returns the differences between the [old] and [new] versions of a spec, so values
+// stored according to the old version can be checked against the new one. A difference is breaking
+// if some value accepted by the old spec may be rejected by the new one, like a removed field or a
+// narrowed type, and compatible otherwise, like a new optional field or Integer changed to Number.
+// Descriptions, defaults and tags don't affect validation, so changes to them are compatible.
+//
+// Required fields are checked before defaults apply, so a new required field is breaking even if
+// it has a default. A removed field is breaking because its values would be lost.
+func Compare(old, new *Spec) []Difference {
+	c := &comparer{
+		oldDefinitions: old.Definitions,
+		newDefinitions: new.Definitions,
+		visited:        make(map[[2]string]bool),
+	}
+	c.compare(old, new, path{})
+	return c.differences
+}
+
+// A comparer holds the state of comparing two specs.
+type comparer struct {
+	oldDefinitions, newDefinitions map[string]*Spec
+
+	// visited contains the pairs of references already compared, to stop at recursive types.
+	visited map[[2]string]bool
+
+	differences []Difference
+}
+
+func (c *comparer) add(path path, breaking bool, format string, args ...any) {
+	c.differences = append(c.differences, Difference{
+		Path:     path.pointer(),
+		Breaking: breaking,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *comparer) compare(old, new *Spec, path path) {
+	if old.Ref != "" && new.Ref != "" {
+		refs := [2]string{old.Ref, new.Ref}
+		if c.visited[refs] {
+			return
+		}
+		c.visited[refs] = true
+	}
+	var err error
+	if old, err = resolve(old, c.oldDefinitions); err != nil {
+		c.add(path, true, "old spec: %v", err)
+		return
+	}
+	if new, err = resolve(new, c.newDefinitions); err != nil {
+		c.add(path, true, "new spec: %v", err)
+		return
+	}
+
+	if old.Description != new.Description {
+		c.add(path, false, "description changed from %q to %q", old.Description, new.Description)
+	}
+	switch {
+	case old.Nullable && !new.Nullable:
+		c.add(path, true, "no longer nullable")
+	case !old.Nullable && new.Nullable:
+		c.add(path, false, "became nullable")
+	}
+	switch {
+	case old.Type == new.Type:
+	case old.Type == Integer && new.Type == Number, old.Type == Datetime && new.Type == String:
+		c.add(path, false, "type widened from %s to %s", old.Type, new.Type)
+	default:
+		// the constraints of different types can't be compared
+		c.add(path, true, "type changed from %s to %s", old.Type, new.Type)
+		return
+	}
+
+	c.compareEnum(old, new, path)
+	c.compareMinimum(path, "minimum", old.Minimum, new.Minimum)
+	c.compareMinimum(path, "exclusive minimum", old.ExclusiveMinimum, new.ExclusiveMinimum)
+	c.compareMaximum(path, "maximum", old.Maximum, new.Maximum)
+	c.compareMaximum(path, "exclusive maximum", old.ExclusiveMaximum, new.ExclusiveMaximum)
+	switch {
+	case old.MultipleOf == nil && new.MultipleOf != nil:
+		c.add(path, true, "multiple of %s added", formatNumber(*new.MultipleOf))
+	case old.MultipleOf != nil && new.MultipleOf == nil:
+		c.add(path, false, "multiple of %s removed", formatNumber(*old.MultipleOf))
+	case old.MultipleOf != nil && *old.MultipleOf != *new.MultipleOf:
+		// multiples of 4 are multiples of 2, but not the other way around
+		breaking := !isMultipleOf(*old.MultipleOf, *new.MultipleOf)
+		c.add(path, breaking, "multiple of changed from %s to %s", formatNumber(*old.MultipleOf), formatNumber(*new.MultipleOf))
+	}
+	c.compareMinimum(path, "minimum length", intToFloat(old.MinLength), intToFloat(new.MinLength))
+	c.compareMaximum(path, "maximum length", intToFloat(old.MaxLength), intToFloat(new.MaxLength))
+	switch {
+	case old.Pattern == new.Pattern:
+	case old.Pattern == "":
+		c.add(path, true, "pattern %q added", new.Pattern)
+	case new.Pattern == "":
+		c.add(path, false, "pattern %q removed", old.Pattern)
+	default:
+		c.add(path, true, "pattern changed from %q to %q", old.Pattern, new.Pattern)
+	}
+
+	switch old.Type {
+	case Object:
+		c.compareObjects(old, new, path)
+	case Array:
+		switch {
+		case old.Elements == nil && new.Elements != nil:
+			c.add(path, true, "elements restricted to %s", new.Elements.Type)
+		case old.Elements != nil && new.Elements == nil:
+			c.add(path, false, "elements no longer restricted")
+		case old.Elements != nil:
+			c.compare(old.Elements, new.Elements, path.append("*"))
+		}
+	}
+}
+
+func (c *comparer) compareObjects(old, new *Spec, path path) {
+	for _, name := range sortedKeys(old.Fields) {
+		if _, ok := new.Fields[name]; !ok {
+			c.add(path.append(name), true, "field removed")
+		}
+	}
+	for _, name := range sortedKeys(new.Fields) {
+		newField := new.Fields[name]
+		oldField, ok := old.Fields[name]
+		fieldPath := path.append(name)
+		if !ok {
+			if newField.Required {
+				c.add(fieldPath, true, "required field added")
+			} else {
+				c.add(fieldPath, false, "optional field added")
+			}
+			continue
+		}
+		switch {
+		case !oldField.Required && newField.Required:
+			c.add(fieldPath, true, "became required")
+		case oldField.Required && !newField.Required:
+			c.add(fieldPath, false, "no longer required")
+		}
+		if (oldField.Default != nil || newField.Default != nil) && !equalValues(newField.Type, oldField.Default, newField.Default) {
+			c.add(fieldPath, false, "default changed from %s to %s", formatValue(oldField.Default), formatValue(newField.Default))
+		}
+		if !slices.Equal(oldField.Tags, newField.Tags) {
+			c.add(fieldPath, false, "tags changed from %q to %q", oldField.Tags, newField.Tags)
+		}
+		c.compare(&oldField.Spec, &newField.Spec, fieldPath)
+	}
+
+	oldClosed := old.AdditionalFields != nil && !*old.AdditionalFields
+	newClosed := new.AdditionalFields != nil && !*new.AdditionalFields
+	switch {
+	case old.Values != nil && new.Values != nil:
+		c.compare(old.Values, new.Values, path.append("*"))
+	case old.Values == nil && new.Values != nil:
+		c.add(path, !oldClosed, "values restricted to %s", new.Values.Type)
+	case old.Values != nil && new.Values == nil:
+		c.add(path, newClosed, "values no longer restricted")
+	case !oldClosed && newClosed:
+		c.add(path, true, "additional fields no longer allowed")
+	case oldClosed && !newClosed:
+		c.add(path, false, "additional fields allowed")
+	}
+}
+
+func (c *comparer) compareEnum(old, new *Spec, path path) {
+	switch {
+	case len(old.Enum) == 0 && len(new.Enum) == 0:
+	case len(old.Enum) == 0:
+		c.add(path, true, "restricted to %s", formatValue(new.Enum))
+	case len(new.Enum) == 0:
+		c.add(path, false, "no longer restricted to %s", formatValue(old.Enum))
+	default:
+		for _, value := range old.Enum {
+			if !slices.ContainsFunc(new.Enum, func(v any) bool { return equalValues(new.Type, value, v) }) {
+				c.add(path, true, "enum value %s removed", formatValue(value))
+			}
+		}
+		for _, value := range new.Enum {
+			if !slices.ContainsFunc(old.Enum, func(v any) bool { return equalValues(new.Type, value, v) }) {
+				c.add(path, false, "enum value %s added", formatValue(value))
+			}
+		}
+	}
+}
+
+// compareMinimum compares lower bounds, which break values when they are added or raised.
+func (c *comparer) compareMinimum(path path, name string, old, new *float64) {
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		c.add(path, true, "%s %s added", name, formatNumber(*new))
+	case new == nil:
+		c.add(path, false, "%s %s removed", name, formatNumber(*old))
+	case *old != *new:
+		c.add(path, *new > *old, "%s changed from %s to %s", name, formatNumber(*old), formatNumber(*new))
+	}
+}
+
+// compareMaximum compares upper bounds, which break values when they are added or lowered.
+func (c *comparer) compareMaximum(path path, name string, old, new *float64) {
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		c.add(path, true, "%s %s added", name, formatNumber(*new))
+	case new == nil:
+		c.add(path, false, "%s %s removed", name, formatNumber(*old))
+	case *old != *new:
+		c.add(path, *new < *old, "%s changed from %s to %s", name, formatNumber(*old), formatNumber(*new))
+	}
+}
+
+func intToFloat(n *int) *float64 {
+	if n == nil {
+		return nil
+	}
+	f := float64(*n)
+	return &f
+}
+
+// formatValue formats a default or enum value as JSON.
+func formatValue(value any) string {
+	if values, ok := value.([]any); ok {
+		converted := make([]any, len(values))
+		for i, v := range values {
+			converted[i] = schemaValue(v)
+		}
+		value = converted
+	}
+	data, err := json.Marshal(schemaValue(value))
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,138 @@
+package jsonspec
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		name     string
+		old, new *Spec
+		want     []Difference
+	}{
+		{
+			name: "same spec",
+			old:  &Spec{Type: Object, Fields: map[string]Field{"a": {Spec: Spec{Type: String}}}},
+			new:  &Spec{Type: Object, Fields: map[string]Field{"a": {Spec: Spec{Type: String}}}},
+		},
+		{
+			name: "fields",
+			old: &Spec{Type: Object, Fields: map[string]Field{
+				"name":    {Spec: Spec{Type: String}, Required: true},
+				"email":   {Spec: Spec{Type: String}},
+				"timeout": {Spec: Spec{Type: Integer}, Default: 30},
+			}},
+			new: &Spec{Type: Object, Fields: map[string]Field{
+				"name":    {Spec: Spec{Type: String, Description: "Full name"}},
+				"timeout": {Spec: Spec{Type: Integer}, Default: 60.0, Tags: []string{"advanced"}},
+				"region":  {Spec: Spec{Type: String}, Required: true, Default: "us"},
+				"team":    {Spec: Spec{Type: String}},
+			}},
+			want: []Difference{
+				{Path: "/email", Breaking: true, Message: "field removed"},
+				{Path: "/name", Message: "no longer required"},
+				{Path: "/name", Message: `description changed from "" to "Full name"`},
+				{Path: "/region", Breaking: true, Message: "required field added"},
+				{Path: "/team", Message: "optional field added"},
+				{Path: "/timeout", Message: "default changed from 30 to 60"},
+				{Path: "/timeout", Message: `tags changed from [] to ["advanced"]`},
+			},
+		},
+		{
+			name: "types",
+			old: &Spec{Type: Array, Elements: &Spec{Type: Object, Fields: map[string]Field{
+				"count":   {Spec: Spec{Type: Integer}},
+				"at":      {Spec: Spec{Type: Datetime, Nullable: true}},
+				"score":   {Spec: Spec{Type: Number}},
+				"comment": {Spec: Spec{Type: String}},
+			}}},
+			new: &Spec{Type: Array, Elements: &Spec{Type: Object, Fields: map[string]Field{
+				"count":   {Spec: Spec{Type: Number, Nullable: true}},
+				"at":      {Spec: Spec{Type: String}},
+				"score":   {Spec: Spec{Type: Integer}},
+				"comment": {Spec: Spec{Type: Object}},
+			}}},
+			want: []Difference{
+				{Path: "/*/at", Breaking: true, Message: "no longer nullable"},
+				{Path: "/*/at", Message: "type widened from datetime to string"},
+				{Path: "/*/comment", Breaking: true, Message: "type changed from string to object"},
+				{Path: "/*/count", Message: "became nullable"},
+				{Path: "/*/count", Message: "type widened from integer to number"},
+				{Path: "/*/score", Breaking: true, Message: "type changed from number to integer"},
+			},
+		},
+		{
+			name: "constraints",
+			old: &Spec{Type: Object, Fields: map[string]Field{
+				"age":    {Spec: Spec{Type: Integer, Minimum: ptr(0.0), Maximum: ptr(150.0), MultipleOf: ptr(2.0)}},
+				"code":   {Spec: Spec{Type: String, MaxLength: ptr(10), Pattern: "^[a-z]+$"}},
+				"region": {Spec: Spec{Type: String, Enum: []any{"us", "eu"}}},
+			}},
+			new: &Spec{Type: Object, Fields: map[string]Field{
+				"age":    {Spec: Spec{Type: Integer, Minimum: ptr(18.0), Maximum: ptr(200.0), MultipleOf: ptr(4.0)}},
+				"code":   {Spec: Spec{Type: String, MinLength: ptr(1), MaxLength: ptr(20)}},
+				"region": {Spec: Spec{Type: String, Enum: []any{"us", "ap"}}},
+			}},
+			want: []Difference{
+				{Path: "/age", Breaking: true, Message: "minimum changed from 0 to 18"},
+				{Path: "/age", Message: "maximum changed from 150 to 200"},
+				{Path: "/age", Breaking: true, Message: "multiple of changed from 2 to 4"},
+				{Path: "/code", Breaking: true, Message: "minimum length 1 added"},
+				{Path: "/code", Message: "maximum length changed from 10 to 20"},
+				{Path: "/code", Message: `pattern "^[a-z]+$" removed`},
+				{Path: "/region", Breaking: true, Message: `enum value "eu" removed`},
+				{Path: "/region", Message: `enum value "ap" added`},
+			},
+		},
+		{
+			name: "objects and maps",
+			old: &Spec{Type: Object, Fields: map[string]Field{
+				"labels":   {Spec: Spec{Type: Object, Values: &Spec{Type: String}}},
+				"settings": {Spec: Spec{Type: Object}},
+				"extra":    {Spec: Spec{Type: Object, AdditionalFields: ptr(false)}},
+			}},
+			new: &Spec{Type: Object, Fields: map[string]Field{
+				"labels":   {Spec: Spec{Type: Object, Values: &Spec{Type: Integer}}},
+				"settings": {Spec: Spec{Type: Object, AdditionalFields: ptr(false)}},
+				"extra":    {Spec: Spec{Type: Object}},
+			}},
+			want: []Difference{
+				{Path: "/extra", Message: "additional fields allowed"},
+				{Path: "/labels/*", Breaking: true, Message: "type changed from string to integer"},
+				{Path: "/settings", Breaking: true, Message: "additional fields no longer allowed"},
+			},
+		},
+		{
+			name: "recursive definitions",
+			old: &Spec{Type: Object, Ref: "Node", Definitions: map[string]*Spec{
+				"Node": {Type: Object, Fields: map[string]Field{
+					"children": {Spec: Spec{Type: Array, Elements: &Spec{Type: Object, Ref: "Node"}}},
+				}},
+			}},
+			new: &Spec{Type: Object, Ref: "Node", Definitions: map[string]*Spec{
+				"Node": {Type: Object, Fields: map[string]Field{
+					"children": {Spec: Spec{Type: Array, Elements: &Spec{Type: Object, Ref: "Node"}}},
+					"name":     {Spec: Spec{Type: String}, Required: true},
+				}},
+			}},
+			want: []Difference{
+				{Path: "/name", Breaking: true, Message: "required field added"},
+			},
+		},
+	}
+	for _, c := range cases {
+		got := Compare(c.old, c.new)
+		if diff := cmp.Diff(c.want, got); diff != "" {
+			t.Errorf("%s: Compare result mismatch (-want +got):\n%s", c.name, diff)
+		}
+	}
+}
+
+func TestDifferenceString(t *testing.T) {
+	d := Difference{Path: "/email", Breaking: true, Message: "field removed"}
+	if got, want := d.String(), "/email: field removed (breaking)"; got != want {
+		t.Errorf("String returned %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,229 @@
+package jsonspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ChangeKind is the kind of a [Change].
+type ChangeKind string
+
+const (
+	// ChangeAdded is a field, definition, element or value spec that was added.
+	ChangeAdded ChangeKind = "added"
+
+	// ChangeRemoved is a field, definition, element or value spec that was removed.
+	ChangeRemoved ChangeKind = "removed"
+
+	// ChangeModified is a property, like the type or the default, with a different value.
+	ChangeModified ChangeKind = "changed"
+)
+
+// A Change is a difference between two specs found by [Diff].
+type Change struct {
+	// Definition is the name of the definition the change is in, or empty for the root spec.
+	Definition string `json:"definition,omitempty"`
+
+	// Path is the JSON Pointer (RFC 6901) to the field that changed, where "*" stands for any
+	// element of an array or any value of an object. It's empty for the root.
+	Path string `json:"path"`
+
+	Kind ChangeKind `json:"kind"`
+
+	// Property is the name of the property that changed, as in the JSON form of a spec, like
+	// "type" or "default". It's empty for added and removed fields and definitions.
+	Property string `json:"property,omitempty"`
+
+	// Before and After are the values before and after the change. They are nil for unset
+	// properties, and they are the [Field] or [Spec] that was removed or added.
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+// Changes are the result of [Diff].
+type Changes []Change
+
+// Diff returns the structural differences between the [old] and [new] specs: fields, elements,
+// values and definitions that were added or removed, and properties of them that changed, like
+// the type, whether a field is required, its default, tags and description. Unlike [Compare], it
+// doesn't follow references: changes to definitions are reported for the definitions themselves.
+func Diff(old, new *Spec) Changes {
+	d := &differ{}
+	d.diff(old, new, path{})
+	for _, name := range sortedKeys(old.Definitions) {
+		if _, ok := new.Definitions[name]; !ok {
+			d.changes = append(d.changes, Change{Definition: name, Kind: ChangeRemoved, Before: old.Definitions[name]})
+		}
+	}
+	for _, name := range sortedKeys(new.Definitions) {
+		definition, ok := old.Definitions[name]
+		if !ok {
+			d.changes = append(d.changes, Change{Definition: name, Kind: ChangeAdded, After: new.Definitions[name]})
+			continue
+		}
+		d.definition = name
+		d.diff(definition, new.Definitions[name], path{})
+	}
+	return d.changes
+}
+
+// A differ holds the state of diffing two specs.
+type differ struct {
+	// definition is the name of the definition being diffed.
+	definition string
+
+	changes Changes
+}
+
+func (d *differ) add(path path, kind ChangeKind, before, after any) {
+	d.changes = append(d.changes, Change{Definition: d.definition, Path: path.pointer(), Kind: kind, Before: before, After: after})
+}
+
+// property records a change to [property] if [before] and [after] differ.
+func (d *differ) property(path path, property string, before, after any) {
+	before, after = schemaValue(before), schemaValue(after)
+	if formatValue(before) == formatValue(after) {
+		return
+	}
+	d.changes = append(d.changes, Change{
+		Definition: d.definition,
+		Path:       path.pointer(),
+		Kind:       ChangeModified,
+		Property:   property,
+		Before:     before,
+		After:      after,
+	})
+}
+
+func (d *differ) diff(old, new *Spec, path path) {
+	d.property(path, "type", old.Type, new.Type)
+	d.property(path, "ref", emptyToNil(old.Ref), emptyToNil(new.Ref))
+	d.property(path, "description", emptyToNil(old.Description), emptyToNil(new.Description))
+	d.property(path, "nullable", old.Nullable, new.Nullable)
+	d.property(path, "enum", emptyToNil(old.Enum), emptyToNil(new.Enum))
+	d.property(path, "minimum", deref(old.Minimum), deref(new.Minimum))
+	d.property(path, "maximum", deref(old.Maximum), deref(new.Maximum))
+	d.property(path, "exclusive_minimum", deref(old.ExclusiveMinimum), deref(new.ExclusiveMinimum))
+	d.property(path, "exclusive_maximum", deref(old.ExclusiveMaximum), deref(new.ExclusiveMaximum))
+	d.property(path, "multiple_of", deref(old.MultipleOf), deref(new.MultipleOf))
+	d.property(path, "min_length", deref(old.MinLength), deref(new.MinLength))
+	d.property(path, "max_length", deref(old.MaxLength), deref(new.MaxLength))
+	d.property(path, "pattern", emptyToNil(old.Pattern), emptyToNil(new.Pattern))
+	d.property(path, "additional_fields", deref(old.AdditionalFields), deref(new.AdditionalFields))
+
+	for _, name := range sortedKeys(old.Fields) {
+		if _, ok := new.Fields[name]; !ok {
+			field := old.Fields[name]
+			d.add(path.append(name), ChangeRemoved, &field, nil)
+		}
+	}
+	for _, name := range sortedKeys(new.Fields) {
+		newField := new.Fields[name]
+		oldField, ok := old.Fields[name]
+		fieldPath := path.append(name)
+		if !ok {
+			d.add(fieldPath, ChangeAdded, nil, &newField)
+			continue
+		}
+		d.property(fieldPath, "required", oldField.Required, newField.Required)
+		d.property(fieldPath, "default", oldField.Default, newField.Default)
+		d.property(fieldPath, "tags", emptyToNil(oldField.Tags), emptyToNil(newField.Tags))
+		d.diff(&oldField.Spec, &newField.Spec, fieldPath)
+	}
+
+	d.diffChild(old.Elements, new.Elements, path.append("*"))
+	d.diffChild(old.Values, new.Values, path.append("*"))
+}
+
+// diffChild diffs the specs for the elements or values of an array or object.
+func (d *differ) diffChild(old, new *Spec, path path) {
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		d.add(path, ChangeAdded, nil, new)
+	case new == nil:
+		d.add(path, ChangeRemoved, old, nil)
+	default:
+		d.diff(old, new, path)
+	}
+}
+
+// emptyToNil returns nil for empty strings and slices, so they are the same as unset properties.
+func emptyToNil(value any) any {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+	case []any:
+		if len(v) == 0 {
+			return nil
+		}
+	case []string:
+		if len(v) == 0 {
+			return nil
+		}
+	}
+	return value
+}
+
+// deref returns the value [p] points to, or nil if [p] is nil.
+func deref[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+// Text renders the changes for people, with one line per change like
+// "~ /timeout: default changed from 30 to 60". Lines start with "+" for additions, "-" for
+// removals and "~" for other changes.
+func (c Changes) Text() string {
+	var b strings.Builder
+	for _, change := range c {
+		location := change.Definition + change.Path
+		if location == "" {
+			location = "(root)"
+		}
+		switch change.Kind {
+		case ChangeAdded:
+			fmt.Fprintf(&b, "+ %s: %s\n", location, summary(change.After))
+		case ChangeRemoved:
+			fmt.Fprintf(&b, "- %s: %s\n", location, summary(change.Before))
+		default:
+			fmt.Fprintf(&b, "~ %s: %s changed from %s to %s\n", location, change.Property, formatValue(change.Before), formatValue(change.After))
+		}
+	}
+	return b.String()
+}
+
+// summary describes an added or removed field or spec, for example "string, required".
+func summary(value any) string {
+	switch value := value.(type) {
+	case *Field:
+		s := summary(&value.Spec)
+		if value.Required {
+			s += ", required"
+		}
+		return s
+	case *Spec:
+		s := string(value.Type)
+		if value.Ref != "" {
+			s = value.Ref
+		}
+		if value.Nullable {
+			s += ", nullable"
+		}
+		return s
+	}
+	return formatValue(value)
+}
+
+// JSON renders the changes as an indented JSON array, for tools.
+func (c Changes) JSON() ([]byte, error) {
+	if c == nil {
+		c = Changes{}
+	}
+	return json.MarshalIndent(c, "", "  ")
+}
@@ -0,0 +1,105 @@
+package jsonspec
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var (
+	diffOld = &Spec{Type: Object, Fields: map[string]Field{
+		"name":    {Spec: Spec{Type: String}, Required: true},
+		"email":   {Spec: Spec{Type: String}},
+		"timeout": {Spec: Spec{Type: Integer}, Default: 30},
+		"tags":    {Spec: Spec{Type: Array, Elements: &Spec{Type: String}}},
+		"manager": {Spec: Spec{Type: Object, Ref: "Person"}},
+	}, Definitions: map[string]*Spec{
+		"Person": {Type: Object, Fields: map[string]Field{"id": {Spec: Spec{Type: Integer}}}},
+	}}
+	diffNew = &Spec{Type: Object, Fields: map[string]Field{
+		"name":    {Spec: Spec{Type: String, Description: "Full name"}, Tags: []string{"pii"}},
+		"timeout": {Spec: Spec{Type: Number}, Default: 60.0},
+		"region":  {Spec: Spec{Type: String}, Required: true},
+		"tags":    {Spec: Spec{Type: Array, Elements: &Spec{Type: String, Enum: []any{"a", "b"}}}},
+		"manager": {Spec: Spec{Type: Object, Ref: "Person", Nullable: true}},
+	}, Definitions: map[string]*Spec{
+		"Person": {Type: Object, Fields: map[string]Field{"id": {Spec: Spec{Type: String}}}},
+		"Team":   {Type: Object},
+	}}
+)
+
+func TestDiff(t *testing.T) {
+	got := Diff(diffOld, diffNew)
+	want := Changes{
+		{Path: "/email", Kind: ChangeRemoved, Before: &Field{Spec: Spec{Type: String}}},
+		{Path: "/manager", Kind: ChangeModified, Property: "nullable", Before: false, After: true},
+		{Path: "/name", Kind: ChangeModified, Property: "required", Before: true, After: false},
+		{Path: "/name", Kind: ChangeModified, Property: "tags", Before: nil, After: []string{"pii"}},
+		{Path: "/name", Kind: ChangeModified, Property: "description", Before: nil, After: "Full name"},
+		{Path: "/region", Kind: ChangeAdded, After: &Field{Spec: Spec{Type: String}, Required: true}},
+		{Path: "/tags/*", Kind: ChangeModified, Property: "enum", Before: nil, After: []any{"a", "b"}},
+		{Path: "/timeout", Kind: ChangeModified, Property: "default", Before: 30, After: 60.0},
+		{Path: "/timeout", Kind: ChangeModified, Property: "type", Before: Integer, After: Number},
+		{Definition: "Person", Path: "/id", Kind: ChangeModified, Property: "type", Before: Integer, After: String},
+		{Definition: "Team", Kind: ChangeAdded, After: &Spec{Type: Object}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Diff result mismatch (-want +got):\n%s", diff)
+	}
+	if got := Diff(diffOld, diffOld); len(got) > 0 {
+		t.Errorf("Diff of the same spec returned %v", got)
+	}
+}
+
+func TestChangesText(t *testing.T) {
+	got := Diff(diffOld, diffNew).Text()
+	want := `- /email: string
+~ /manager: nullable changed from false to true
+~ /name: required changed from true to false
+~ /name: tags changed from null to ["pii"]
+~ /name: description changed from null to "Full name"
++ /region: string, required
+~ /tags/*: enum changed from null to ["a","b"]
+~ /timeout: default changed from 30 to 60
+~ /timeout: type changed from "integer" to "number"
+~ Person/id: type changed from "integer" to "string"
++ Team: object
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Text result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestChangesJSON(t *testing.T) {
+	changes := Changes{
+		{Path: "/timeout", Kind: ChangeModified, Property: "default", Before: 30, After: 60},
+		{Path: "/region", Kind: ChangeAdded, After: &Field{Spec: Spec{Type: String}, Required: true}},
+	}
+	got, err := changes.JSON()
+	if err != nil {
+		t.Fatalf("JSON returned error: %v", err)
+	}
+	want := `[
+  {
+    "path": "/timeout",
+    "kind": "changed",
+    "property": "default",
+    "before": 30,
+    "after": 60
+  },
+  {
+    "path": "/region",
+    "kind": "added",
+    "after": {
+      "type": "string",
+      "required": true
+    }
+  }
+]`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("JSON result mismatch (-want +got):\n%s", diff)
+	}
+	if got, _ := Changes(nil).JSON(); string(got) != "[]" {
+		t.Errorf("JSON of no changes returned %s, want []", got)
+	}
+}
@@ -0,0 +1,283 @@
+package jsonspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Dump converts [value] to a document in the format described by the spec generated for its type
+// by [For], the inverse of [Load]. Keys follow the same naming convention as in Load, objects
+// become map[string]any, arrays []any, integers int64 or uint64 (json.Number for big.Int), and
+// datetimes strings in RFC 3339 format. Nil maps and slices in optional fields are left out, since
+// Load leaves absent fields alone, and so are empty fields with the omitempty option in their json
+// tag, like with encoding/json, unless they have a default. Structs stored in interfaces, like the
+// values of a map[string]any, are dumped with the spec for their own type.
+//
+// The document is validated against the spec, so Dump returns a [*ValidationError] for values that
+// Load would reject, like an empty string in a field with an enum that doesn't include it.
+func Dump(value any) (any, error) {
+	return DumpOptions{}.Dump(value)
+}
+
+// DumpJSON is like [Dump], but returns the document encoded as JSON. If DumpJSON succeeds,
+// [LoadJSON] loads the document back into a value equal to [value], except that times are equal as
+// points in time and nil slices and maps in required fields become empty.
+func DumpJSON(value any) ([]byte, error) {
+	return DumpOptions{}.DumpJSON(value)
+}
+
+// DumpOptions are options for [Dump] and [DumpJSON].
+type DumpOptions struct {
+	// OmitDefaults leaves out fields equal to their default, which Load sets when they are absent.
+	OmitDefaults bool
+}
+
+// Dump is like [Dump] with the options [o].
+func (o DumpOptions) Dump(value any) (any, error) {
+	d := &dumper{omitDefaults: o.OmitDefaults}
+	document, err := d.dumpValue(value)
+	if err != nil || d.spec == nil {
+		return document, err
+	}
+	if err := d.spec.Validate(document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// DumpJSON is like [DumpJSON] with the options [o].
+func (o DumpOptions) DumpJSON(value any) ([]byte, error) {
+	document, err := o.Dump(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
+
+// A dumper holds the state of dumping one value.
+type dumper struct {
+	// spec is the spec of the dumped value, set by [dumper.dumpValue].
+	spec *Spec
+
+	// definitions are the definitions of the root spec.
+	definitions map[string]*Spec
+
+	// redact is true if the values of secret fields are replaced by [RedactedValue].
+	redact bool
+
+	// omitDefaults is true if fields equal to their default are left out.
+	omitDefaults bool
+}
+
+// dumpValue returns the document for [value] with the spec generated by [For].
+func (d *dumper) dumpValue(value any) (any, error) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return nil, nil
+	}
+	spec, err := specForType(v.Type())
+	if err != nil {
+		return nil, err
+	}
+	d.spec = spec
+	d.definitions = spec.Definitions
+	return d.dump(spec, v, nil)
+}
+
+// dump returns the document for [v], which is a value of the type [spec] was generated for.
+func (d *dumper) dump(spec *Spec, v reflect.Value, path path) (any, error) {
+	spec, err := resolve(spec, d.definitions)
+	if err != nil {
+		return nil, err
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	switch spec.Type {
+	case Boolean:
+		if v.Kind() == reflect.Bool {
+			return v.Bool(), nil
+		}
+	case String:
+		if v.Kind() == reflect.String {
+			return v.String(), nil
+		}
+	case Integer:
+		switch {
+		case v.Type() == bigIntType && v.CanInterface():
+			n := v.Interface().(big.Int)
+			return json.Number(n.String()), nil
+		case v.CanInt():
+			return v.Int(), nil
+		case v.CanUint():
+			return v.Uint(), nil
+		}
+	case Number:
+		if v.Kind() == reflect.Float32 {
+			// the shortest decimal for the float32, not for its float64 conversion
+			return strconv.ParseFloat(strconv.FormatFloat(v.Float(), 'g', -1, 32), 64)
+		}
+		if v.CanFloat() {
+			return v.Float(), nil
+		}
+	case Datetime:
+		if v.Type() == timeType && v.CanInterface() {
+			return v.Interface().(time.Time).Format(time.RFC3339Nano), nil
+		}
+	case Array:
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			result := make([]any, v.Len())
+			for i := range result {
+				element, err := d.dumpChild(spec.Elements, v.Index(i), path.append(i))
+				if err != nil {
+					return nil, err
+				}
+				result[i] = element
+			}
+			return result, nil
+		}
+	case Object:
+		switch v.Kind() {
+		case reflect.Map:
+			result := make(map[string]any, v.Len())
+			iter := v.MapRange()
+			for iter.Next() {
+				key := dumpKey(iter.Key())
+				value, err := d.dumpChild(spec.Values, iter.Value(), path.append(key))
+				if err != nil {
+					return nil, err
+				}
+				result[key] = value
+			}
+			return result, nil
+		case reflect.Struct:
+			return d.dumpStruct(spec, v, path)
+		}
+	}
+	return nil, fmt.Errorf("%scannot dump %v as %s", path.prefix(), v.Type(), spec.Type)
+}
+
+// dumpKey returns the object key for the map key [k], which is a string or an integer.
+func dumpKey(k reflect.Value) string {
+	switch {
+	case k.CanInt():
+		return strconv.FormatInt(k.Int(), 10)
+	case k.CanUint():
+		return strconv.FormatUint(k.Uint(), 10)
+	}
+	return k.String()
+}
+
+// dumpChild dumps an element of an array or a value of an object. [spec] is nil if the Go type of
+// the values is an interface. Structs, maps and slices in interfaces are dumped with the spec for
+// their own type, like [dumper.dumpValue] does, so their keys are translated and their secrets
+// redacted. Other values are returned as they are.
+func (d *dumper) dumpChild(spec *Spec, v reflect.Value, path path) (any, error) {
+	if spec != nil {
+		return d.dump(spec, v, path)
+	}
+	value := v
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+	default:
+		return value.Interface(), nil
+	}
+	if isNil(v) {
+		return nil, nil
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() == reflect.Interface {
+		// For has no spec for arrays of interfaces
+		result := make([]any, v.Len())
+		for i := range result {
+			element, err := d.dumpChild(nil, v.Index(i), path.append(i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = element
+		}
+		return result, nil
+	}
+	spec, err := specForType(v.Type())
+	if err != nil {
+		return nil, fmt.Errorf("%s%v", path.prefix(), err)
+	}
+	// the spec has its own definitions
+	child := &dumper{definitions: spec.Definitions, redact: d.redact, omitDefaults: d.omitDefaults}
+	return child.dump(spec, v, path)
+}
+
+func (d *dumper) dumpStruct(spec *Spec, v reflect.Value, path path) (any, error) {
+	structFields, err := fieldsOf(v.Type())
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]any, len(structFields))
+	for _, structField := range structFields {
+		key := structField.key
+		field, ok := spec.Fields[key]
+		if !ok {
+			continue
+		}
+		value, err := v.FieldByIndexErr(structField.index)
+		if err != nil {
+			// a nil embedded pointer has no fields to dump
+			continue
+		}
+		if (value.Kind() == reflect.Slice || value.Kind() == reflect.Map) && value.IsNil() && !field.Required {
+			// null would be rejected, and Load leaves absent fields nil
+			continue
+		}
+		if structField.omitEmpty && field.Default == nil && isEmptyValue(value) {
+			// like encoding/json, unless Load would set the default instead of the empty value
+			continue
+		}
+		if d.redact && isSecret(&field) && !isNil(value) {
+			result[key] = RedactedValue
+			continue
+		}
+		dumped, err := d.dump(&field.Spec, value, path.append(key))
+		if err != nil {
+			return nil, err
+		}
+		if d.omitDefaults && field.Default != nil && equalValues(field.Type, dumped, field.Default) {
+			continue
+		}
+		result[key] = dumped
+	}
+	return result, nil
+}
+
+// isNil returns true if [v] is a nil pointer, interface, map or slice.
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// isEmptyValue returns true if [v] is empty according to the omitempty option of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
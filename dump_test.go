@@ -0,0 +1,198 @@
+package jsonspec
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type DumpedArgs struct {
+	OAuthCredentials
+	Workspace string    `required:"true"`
+	Region    Region    `default:"us"`
+	Timeout   int       `default:"30"`
+	Retries   *uint8    `default:"3"`
+	Ratio     float32   `default:"0.5"`
+	Started   time.Time `default:"2024-01-01T00:00:00Z"`
+	Scopes    []string
+	Labels    map[string]int
+	Tree      *Node
+	Offices   []Address
+}
+
+func TestDump(t *testing.T) {
+	args := DumpedArgs{
+		OAuthCredentials: OAuthCredentials{ClientID: "abc", ClientSecret: "hunter2"},
+		Workspace:        "acme",
+		Region:           "eu",
+		Timeout:          30,
+		Ratio:            0.1,
+		Started:          time.Date(2024, 3, 7, 11, 38, 47, 500, time.UTC),
+		Labels:           map[string]int{"tier": 1},
+		Tree:             &Node{Name: "root", Children: []Node{{Name: "leaf"}}},
+		Offices:          []Address{{Street: "Main St"}},
+	}
+	got, err := Dump(args)
+	if err != nil {
+		t.Fatalf("Dump returned error: %v", err)
+	}
+	want := map[string]any{
+		"client_id":     "abc",
+		"client_secret": "hunter2",
+		"workspace":     "acme",
+		"region":        "eu",
+		"timeout":       int64(30),
+		"retries":       nil,
+		"ratio":         0.1,
+		"started":       "2024-03-07T11:38:47.0000005Z",
+		"labels":        map[string]any{"tier": int64(1)},
+		"tree": map[string]any{
+			"name":     "root",
+			"children": []any{map[string]any{"name": "leaf", "parent": nil}},
+			"parent":   nil,
+		},
+		"offices": []any{map[string]any{"street": "Main St"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Dump result mismatch (-want +got):\n%s", diff)
+	}
+
+	// the document is valid and loads back into the same value
+	data, err := DumpJSON(args)
+	if err != nil {
+		t.Fatalf("DumpJSON returned error: %v", err)
+	}
+	var loaded DumpedArgs
+	if err := LoadJSON(data, &loaded); err != nil {
+		t.Fatalf("LoadJSON(%s) returned error: %v", data, err)
+	}
+	if diff := cmp.Diff(args, loaded); diff != "" {
+		t.Errorf("LoadJSON(DumpJSON(x)) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDumpOmitDefaults(t *testing.T) {
+	retries := uint8(3)
+	args := DumpedArgs{
+		Workspace: "acme",
+		Region:    "us",
+		Timeout:   30,
+		Retries:   &retries,
+		Ratio:     0.5,
+		Started:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	data, err := DumpOptions{OmitDefaults: true}.DumpJSON(args)
+	if err != nil {
+		t.Fatalf("DumpJSON returned error: %v", err)
+	}
+	want := `{"client_id":"","client_secret":"","tree":null,"workspace":"acme"}`
+	if string(data) != want {
+		t.Errorf("DumpJSON returned %s, want %s", data, want)
+	}
+	var loaded DumpedArgs
+	if err := LoadJSON(data, &loaded); err != nil {
+		t.Fatalf("LoadJSON(%s) returned error: %v", data, err)
+	}
+	if diff := cmp.Diff(args, loaded); diff != "" {
+		t.Errorf("LoadJSON(DumpJSON(x)) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDumpOmitEmpty(t *testing.T) {
+	type Args struct {
+		Name    string   `json:",omitempty"`
+		Count   int      `json:"count,omitempty"`
+		Scopes  []string `json:",omitempty"`
+		Owner   *string  `json:",omitempty"`
+		Timeout int      `json:",omitempty" default:"30"`
+		Region  string
+	}
+	data, err := DumpJSON(Args{Scopes: []string{}})
+	if err != nil {
+		t.Fatalf("DumpJSON returned error: %v", err)
+	}
+	want := `{"region":"","timeout":0}`
+	if string(data) != want {
+		t.Errorf("DumpJSON returned %s, want %s", data, want)
+	}
+}
+
+func TestDumpBigInt(t *testing.T) {
+	const large = "123456789012345678901234567890"
+	n, _ := new(big.Int).SetString(large, 10)
+	data, err := DumpJSON(struct{ ID *big.Int }{n})
+	if err != nil {
+		t.Fatalf("DumpJSON returned error: %v", err)
+	}
+	if want := `{"id":` + large + `}`; string(data) != want {
+		t.Errorf("DumpJSON returned %s, want %s", data, want)
+	}
+}
+
+func TestDumpIntegerKeys(t *testing.T) {
+	type Args struct {
+		Ports  map[uint16]string
+		Shards map[int8][]string
+	}
+	args := Args{
+		Ports:  map[uint16]string{80: "http", 443: "https"},
+		Shards: map[int8][]string{-1: {"a"}, 2: {"b", "c"}},
+	}
+	data, err := DumpJSON(args)
+	if err != nil {
+		t.Fatalf("DumpJSON returned error: %v", err)
+	}
+	want := `{"ports":{"443":"https","80":"http"},"shards":{"-1":["a"],"2":["b","c"]}}`
+	if string(data) != want {
+		t.Errorf("DumpJSON returned %s, want %s", data, want)
+	}
+	var loaded Args
+	if err := LoadJSON(data, &loaded); err != nil {
+		t.Fatalf("LoadJSON(%s) returned error: %v", data, err)
+	}
+	if diff := cmp.Diff(args, loaded); diff != "" {
+		t.Errorf("LoadJSON(DumpJSON(x)) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDumpInvalid(t *testing.T) {
+	type Args struct {
+		Region string `enum:"us,eu"`
+		Port   int    `min:"1"`
+	}
+	// zero values that Load would reject
+	cases := []struct {
+		value any
+		want  string
+	}{
+		{Args{Port: 80}, "/region"},
+		{Args{Region: "eu"}, "/port"},
+	}
+	for _, test := range cases {
+		_, err := DumpJSON(test.value)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Path != test.want {
+			t.Errorf("DumpJSON(%+v) returned %v, want an error for %s", test.value, err, test.want)
+		}
+	}
+	// redaction doesn't validate
+	if _, err := MarshalRedacted(Args{}); err != nil {
+		t.Errorf("MarshalRedacted returned error: %v", err)
+	}
+}
+
+func TestDumpError(t *testing.T) {
+	cases := []any{
+		func() {},
+		struct{ C chan int }{},
+		map[float64]string{},
+	}
+	for _, value := range cases {
+		if _, err := Dump(value); err == nil {
+			t.Errorf("Dump(%v) did not return error", value)
+		}
+	}
+}
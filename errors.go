@@ -0,0 +1,154 @@
+package jsonspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An ErrorCode identifies the kind of problem reported by a [ValidationError].
+type ErrorCode string
+
+// Constants for error codes.
+const (
+	// CodeTypeMismatch means the value doesn't have the type required by the spec.
+	CodeTypeMismatch ErrorCode = "type_mismatch"
+	// CodeRequired means a required field is missing.
+	CodeRequired ErrorCode = "required"
+	// CodeUnknownField means an object contains a field that isn't in the spec, and the spec
+	// doesn't allow additional fields.
+	CodeUnknownField ErrorCode = "unknown_field"
+	// CodeOutOfRange means a number is less than the minimum or greater than the maximum.
+	CodeOutOfRange ErrorCode = "out_of_range"
+	// CodeNotMultipleOf means a number isn't a multiple of the value required by the spec.
+	CodeNotMultipleOf ErrorCode = "not_multiple_of"
+	// CodeTooShort means a string has fewer characters than the minimum length.
+	CodeTooShort ErrorCode = "too_short"
+	// CodeTooLong means a string has more characters than the maximum length.
+	CodeTooLong ErrorCode = "too_long"
+	// CodePatternMismatch means a string doesn't match the pattern of the spec.
+	CodePatternMismatch ErrorCode = "pattern_mismatch"
+	// CodeNotInEnum means a value isn't one of the values listed in the enum of the spec.
+	CodeNotInEnum ErrorCode = "not_in_enum"
+	// CodeInvalidFormat means the value has the right JSON type but not the right format, for
+	// example a string that isn't a valid datetime.
+	CodeInvalidFormat ErrorCode = "invalid_format"
+	// CodeInvalidSpec means the spec itself is invalid, for example because it has a pattern that
+	// doesn't compile or a reference to an undefined definition.
+	CodeInvalidSpec ErrorCode = "invalid_spec"
+)
+
+// A ValidationError describes a value that doesn't match a spec. The errors returned by
+// [Spec.Validate] and [Spec.ValidateAll] are (or contain) values of type *ValidationError, so they
+// can be inspected with [errors.As].
+type ValidationError struct {
+	// Path is the location of the invalid value as a JSON Pointer (RFC 6901), for example
+	// "/customers/0/name". The empty string refers to the whole document.
+	Path string
+
+	// Code identifies the kind of problem.
+	Code ErrorCode
+
+	// Expected is the type the spec requires for the value.
+	Expected Type
+
+	// Actual is the kind of the value that was found: "null", "boolean", "string", "number",
+	// "object", "array" or "datetime". It's empty if the value is missing.
+	Actual string
+
+	// Message describes the problem, for example "expected a string".
+	Message string
+
+	// prefix describes the location in the format of error messages, for example
+	// "customers: element 0: ".
+	prefix string
+}
+
+func newValidationError(path path, code ErrorCode, expected Type, value any, message string) *ValidationError {
+	return &ValidationError{
+		Path:     path.pointer(),
+		Code:     code,
+		Expected: expected,
+		Actual:   kindOf(value),
+		Message:  message,
+		prefix:   path.prefix(),
+	}
+}
+
+func (e *ValidationError) Error() string {
+	return e.prefix + e.Message
+}
+
+// kindOf returns the kind of a value in terms of JSON types.
+func kindOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case time.Time:
+		return "datetime"
+	case json.Number, big.Int, *big.Int:
+		return "number"
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// A path is the location of a value inside a JSON document. Each element is either a string (the
+// name of an object field) or an int (the index of an array element).
+type path []any
+
+// append returns a new path with [elem] added at the end.
+func (p path) append(elem any) path {
+	result := make(path, len(p), len(p)+1)
+	copy(result, p)
+	return append(result, elem)
+}
+
+// prefix returns the path formatted as a prefix for error messages, for example
+// "customers: element 0: ".
+func (p path) prefix() string {
+	var b strings.Builder
+	for _, elem := range p {
+		switch elem := elem.(type) {
+		case int:
+			fmt.Fprintf(&b, "element %d: ", elem)
+		default:
+			fmt.Fprintf(&b, "%s: ", elem)
+		}
+	}
+	return b.String()
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// pointer returns the path as a JSON Pointer (RFC 6901), for example "/customers/0/name".
+func (p path) pointer() string {
+	var b strings.Builder
+	for _, elem := range p {
+		b.WriteByte('/')
+		switch elem := elem.(type) {
+		case int:
+			b.WriteString(strconv.Itoa(elem))
+		default:
+			b.WriteString(pointerEscaper.Replace(fmt.Sprint(elem)))
+		}
+	}
+	return b.String()
+}
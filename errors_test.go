@@ -0,0 +1,87 @@
+package jsonspec
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestValidationError(t *testing.T) {
+	cases := []struct {
+		o, value any
+		want     *ValidationError
+	}{
+		{
+			"",
+			123,
+			&ValidationError{Path: "", Code: CodeTypeMismatch, Expected: String, Actual: "number", Message: "expected a string"},
+		},
+		{
+			[]int{},
+			[]any{1, "two"},
+			&ValidationError{Path: "/1", Code: CodeTypeMismatch, Expected: Integer, Actual: "string", Message: "expected an integer"},
+		},
+		{
+			struct{ CreatedAt time.Time }{},
+			map[string]any{"created_at": "yesterday"},
+			&ValidationError{Path: "/created_at", Code: CodeInvalidFormat, Expected: Datetime, Actual: "string", Message: "expected a datetime in RFC3339 format"},
+		},
+		{
+			NestedStruct{},
+			map[string]any{
+				"customers": []any{
+					map[string]any{
+						"name": "Jane Doe",
+						"contact_details": map[string]any{
+							"phone_numbers": []any{
+								map[string]any{"number": "123"},
+								map[string]any{"number": "456"},
+								map[string]any{"country_code": "001"},
+							},
+						},
+					},
+				},
+			},
+			&ValidationError{
+				Path:     "/customers/0/contact_details/phone_numbers/2/number",
+				Code:     CodeRequired,
+				Expected: String,
+				Message:  "number is required",
+			},
+		},
+	}
+	for _, c := range cases {
+		spec, err := For(c.o)
+		if err != nil {
+			t.Fatalf("For(%v) returned error: %v", c.o, err)
+		}
+		err = spec.ValidateAll(c.value)
+		var got *ValidationError
+		if !errors.As(err, &got) {
+			t.Errorf("spec.ValidateAll(%v) returned %v, want a *ValidationError", c.value, err)
+			continue
+		}
+		if diff := cmp.Diff(c.want, got, cmpopts.IgnoreUnexported(ValidationError{})); diff != "" {
+			t.Errorf("spec.ValidateAll(%v) error mismatch (-want +got):\n%s", c.value, diff)
+		}
+	}
+}
+
+func TestPathPointer(t *testing.T) {
+	cases := []struct {
+		path path
+		want string
+	}{
+		{nil, ""},
+		{path{"customers", 0, "name"}, "/customers/0/name"},
+		{path{"a/b", "m~n"}, "/a~1b/m~0n"},
+	}
+	for _, c := range cases {
+		if got := c.path.pointer(); got != c.want {
+			t.Errorf("%v.pointer() == %q, want %q", c.path, got, c.want)
+		}
+	}
+}
@@ -3,8 +3,12 @@ package jsonspec
 import (
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -19,36 +23,239 @@ func For(o any) (*Spec, error) {
 	return specForType(typ)
 }
 
+// An Enumerator is a type that only has a fixed set of valid values. [For] uses the values
+// returned by EnumValues as the Enum of the spec for the type. EnumValues is called on the zero
+// value of the type. It's ignored for objects and arrays, so a struct that embeds an Enumerator
+// isn't one.
+type Enumerator interface {
+	EnumValues() []any
+}
+
+var enumeratorType = reflect.TypeOf((*Enumerator)(nil)).Elem()
+
+// A Strict type is a struct type whose objects only accept the fields in its spec, like an object
+// field with the additional_fields:"false" tag, which can't be put on the root of a spec. [For]
+// sets AdditionalFields to false in the spec for the type if StrictFields returns true.
+// StrictFields is called on the zero value of the type. Like the fields of an embedded struct, the
+// method is promoted, so a struct that embeds a Strict type is strict too unless it defines
+// StrictFields itself.
+type Strict interface {
+	StrictFields() bool
+}
+
+var strictType = reflect.TypeOf((*Strict)(nil)).Elem()
+
+// specForType generates a [Spec] for a type. Named struct types that are recursive or used more
+// than once are turned into definitions, referenced with [Spec.Ref].
 func specForType(typ reflect.Type) (*Spec, error) {
-	if typ.PkgPath() == "time" && typ.Name() == "Time" {
+	g := newGenerator(typ)
+	spec, err := g.specForType(typ)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.definitions) > 0 {
+		spec.Definitions = g.definitions
+	}
+	return spec, nil
+}
+
+// A generator holds the state of generating a spec for a type.
+type generator struct {
+	// shared contains the named struct types that are turned into definitions.
+	shared map[reflect.Type]bool
+
+	// names contains the names of the definitions for shared types.
+	names map[reflect.Type]string
+
+	definitions map[string]*Spec
+}
+
+// newGenerator returns a generator for [typ]. It checks in advance which named struct types
+// reachable from [typ] are recursive or used more than once.
+func newGenerator(typ reflect.Type) *generator {
+	g := &generator{
+		shared:      make(map[reflect.Type]bool),
+		names:       make(map[reflect.Type]string),
+		definitions: make(map[string]*Spec),
+	}
+	g.scan(typ, make(map[reflect.Type]int), make(map[reflect.Type]bool))
+	return g
+}
+
+// scan finds the shared types reachable from [typ]. [counts] holds the number of times each named
+// struct type was found and [visiting] the types being scanned.
+func (g *generator) scan(typ reflect.Type, counts map[reflect.Type]int, visiting map[reflect.Type]bool) {
+	switch typ.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		g.scan(typ.Elem(), counts, visiting)
+	case reflect.Struct:
+		if typ == timeType || typ == bigIntType {
+			return
+		}
+		if typ.Name() != "" {
+			counts[typ]++
+			if visiting[typ] || counts[typ] > 1 {
+				g.shared[typ] = true
+				return
+			}
+			visiting[typ] = true
+			defer delete(visiting, typ)
+		}
+		structFields, err := fieldsOf(typ)
+		if err != nil {
+			// reported when generating the spec
+			return
+		}
+		for _, structField := range structFields {
+			g.scan(structField.Type, counts, visiting)
+		}
+	}
+}
+
+// definitionName returns a unique name for the definition of [typ].
+func (g *generator) definitionName(typ reflect.Type) string {
+	base := nonIdentifierRe.ReplaceAllString(typ.Name(), "_")
+	name := base
+	for i := 2; g.definitions[name] != nil; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	return name
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	bigIntType      = reflect.TypeOf(big.Int{})
+	nonIdentifierRe = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+func (g *generator) specForType(typ reflect.Type) (*Spec, error) {
+	if typ.Kind() == reflect.Pointer {
+		spec, err := g.specForType(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		spec.Nullable = true
+		return spec, nil
+	}
+	if g.shared[typ] {
+		return g.reference(typ)
+	}
+	spec, err := g.specForKind(typ)
+	if err != nil {
+		return nil, err
+	}
+	isEnumerator := typ.Implements(enumeratorType) || reflect.PointerTo(typ).Implements(enumeratorType)
+	if isEnumerator && spec.Type != Object && spec.Type != Array {
+		spec.Enum, err = enumFor(typ, spec.Type)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return spec, nil
+}
+
+// reference returns a spec that refers to the definition for [typ], generating the definition if
+// needed.
+func (g *generator) reference(typ reflect.Type) (*Spec, error) {
+	name, ok := g.names[typ]
+	if !ok {
+		name = g.definitionName(typ)
+		g.names[typ] = name
+		// reserve the name, the spec is set below
+		g.definitions[name] = &Spec{Type: Object}
+		spec, err := g.specForObject(typ)
+		if err != nil {
+			return nil, err
+		}
+		g.definitions[name] = spec
+	}
+	return &Spec{Type: Object, Ref: name}, nil
+}
+
+func (g *generator) specForKind(typ reflect.Type) (*Spec, error) {
+	if typ == timeType {
 		return &Spec{Type: Datetime}, nil
 	}
+	if typ == bigIntType {
+		return &Spec{Type: Integer}, nil
+	}
 	switch typ.Kind() {
 	case reflect.Bool:
 		return &Spec{Type: Boolean}, nil
 	case reflect.String:
 		return &Spec{Type: String}, nil
-	case reflect.Int:
+	case reflect.Int, reflect.Int64:
 		return &Spec{Type: Integer}, nil
-	case reflect.Float64:
+	case reflect.Int8:
+		return integerSpec(math.MinInt8, math.MaxInt8), nil
+	case reflect.Int16:
+		return integerSpec(math.MinInt16, math.MaxInt16), nil
+	case reflect.Int32:
+		return integerSpec(math.MinInt32, math.MaxInt32), nil
+	case reflect.Uint8:
+		return integerSpec(0, math.MaxUint8), nil
+	case reflect.Uint16:
+		return integerSpec(0, math.MaxUint16), nil
+	case reflect.Uint32:
+		return integerSpec(0, math.MaxUint32), nil
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		// the maximum can't be represented exactly as a float64, Load checks it instead
+		minimum := 0.0
+		return &Spec{Type: Integer, Minimum: &minimum}, nil
+	case reflect.Float32, reflect.Float64:
 		return &Spec{Type: Number}, nil
 	case reflect.Struct:
-		return specForObject(typ)
+		return g.specForObject(typ)
 	case reflect.Slice:
-		return specForArray(typ)
+		return g.specForArray(typ)
 	case reflect.Map:
-		return &Spec{Type: Object}, nil
+		return g.specForMap(typ)
 	}
 
 	return nil, fmt.Errorf("cannot generate spec for type %v", typ)
 }
 
-func specForObject(typ reflect.Type) (*Spec, error) {
-	numFields := typ.NumField()
+// integerSpec returns the spec for an integer type with the range [minimum, maximum].
+func integerSpec(minimum, maximum float64) *Spec {
+	return &Spec{Type: Integer, Minimum: &minimum, Maximum: &maximum}
+}
+
+// enumFor returns the values of an [Enumerator] type, converted to the plain Go types used for
+// values of type [t].
+func enumFor(typ reflect.Type, t Type) ([]any, error) {
+	// the method set of *T includes the methods of T, so this works for both kinds of receivers
+	values := reflect.New(typ).Interface().(Enumerator).EnumValues()
+	enum := make([]any, len(values))
+	for i, value := range values {
+		v := reflect.ValueOf(value)
+		switch {
+		case t == Boolean && v.Kind() == reflect.Bool:
+			enum[i] = v.Bool()
+		case t == String && v.Kind() == reflect.String:
+			enum[i] = v.String()
+		case t == Integer && v.CanInt():
+			enum[i] = int(v.Int())
+		case t == Integer && v.CanUint():
+			enum[i] = int(v.Uint())
+		case t == Number && v.CanFloat():
+			enum[i] = v.Float()
+		case t == Datetime && v.Type() == timeType:
+			enum[i] = value
+		default:
+			return nil, fmt.Errorf("invalid enum value for %v: %v", typ, value)
+		}
+	}
+	return enum, nil
+}
+
+func (g *generator) specForObject(typ reflect.Type) (*Spec, error) {
+	structFields, err := fieldsOf(typ)
+	if err != nil {
+		return nil, err
+	}
 	fields := make(map[string]Field)
-	for i := 0; i < numFields; i++ {
-		structField := typ.Field(i)
-		name, field, err := fieldFor(structField)
+	for _, structField := range structFields {
+		name, field, err := g.fieldFor(structField.StructField)
 		if err != nil {
 			return nil, err
 		}
@@ -58,11 +265,128 @@ func specForObject(typ reflect.Type) (*Spec, error) {
 		Type:   Object,
 		Fields: fields,
 	}
+	if typ.Implements(strictType) || reflect.PointerTo(typ).Implements(strictType) {
+		if reflect.New(typ).Interface().(Strict).StrictFields() {
+			additionalFields := false
+			spec.AdditionalFields = &additionalFields
+		}
+	}
 	return spec, nil
 }
 
-func specForArray(typ reflect.Type) (*Spec, error) {
-	elementSpec, err := specForType(typ.Elem())
+// A structField is a field of a struct type that appears as a key in JSON objects. It may be
+// promoted from an embedded struct.
+type structField struct {
+	reflect.StructField
+
+	// index is the sequence of indices to get to the field from the outer struct, as used by
+	// [reflect.Value.FieldByIndex].
+	index []int
+
+	// key is the key of the field in JSON objects.
+	key string
+
+	// omitEmpty is true if the json tag of the field has the omitempty option.
+	omitEmpty bool
+}
+
+// fieldsOf returns the fields of a struct type that appear as keys in JSON objects, sorted by their
+// index. Like encoding/json, the fields of embedded structs without a name in their json tag are
+// promoted to the outer struct, and if several fields have the same key, the one that is nested
+// the least wins. Unlike encoding/json, fields with the same key at the same depth are reported as
+// an error instead of being dropped.
+func fieldsOf(typ reflect.Type) ([]structField, error) {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var result []structField
+	seen := make(map[string]bool)
+	visited := make(map[reflect.Type]bool)
+	current := []embedded{{typ: typ}}
+	for len(current) > 0 {
+		// fields at the current depth, by key
+		var next []embedded
+		fields := make(map[string][]structField)
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i := 0; i < e.typ.NumField(); i++ {
+				f := e.typ.Field(i)
+				index := append(append([]int(nil), e.index...), i)
+				if t, ok := embeddedStruct(f); ok {
+					if f.Type.Kind() == reflect.Pointer && !f.IsExported() {
+						return nil, fmt.Errorf("field %s: cannot use embedded pointer to unexported type %v", f.Name, t)
+					}
+					next = append(next, embedded{typ: t, index: index})
+					continue
+				}
+				key, omitEmpty, ok := fieldKey(f)
+				if !ok || seen[key] {
+					continue
+				}
+				fields[key] = append(fields[key], structField{
+					StructField: f,
+					index:       index,
+					key:         key,
+					omitEmpty:   omitEmpty,
+				})
+			}
+		}
+		for _, key := range sortedKeys(fields) {
+			if len(fields[key]) > 1 {
+				names := make([]string, len(fields[key]))
+				for i, f := range fields[key] {
+					names[i] = fieldPath(typ, f.index)
+				}
+				return nil, fmt.Errorf("conflicting fields for key %q in %v: %s", key, typ, strings.Join(names, ", "))
+			}
+			seen[key] = true
+			result = append(result, fields[key][0])
+		}
+		current = next
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return slices.Compare(result[i].index, result[j].index) < 0
+	})
+	return result, nil
+}
+
+// embeddedStruct returns the struct type of [f] if it is an embedded struct (or pointer to struct)
+// whose fields are promoted.
+func embeddedStruct(f reflect.StructField) (reflect.Type, bool) {
+	if !f.Anonymous {
+		return nil, false
+	}
+	if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" {
+		// a named embedded struct is a regular field
+		return nil, false
+	}
+	t := f.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t, t.Kind() == reflect.Struct
+}
+
+// fieldPath returns the names of the fields at [index] in [typ], separated by dots.
+func fieldPath(typ reflect.Type, index []int) string {
+	names := make([]string, len(index))
+	for i, n := range index {
+		if typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		f := typ.Field(n)
+		names[i] = f.Name
+		typ = f.Type
+	}
+	return strings.Join(names, ".")
+}
+
+func (g *generator) specForArray(typ reflect.Type) (*Spec, error) {
+	elementSpec, err := g.specForType(typ.Elem())
 	if err != nil {
 		return nil, err
 	}
@@ -73,21 +397,79 @@ func specForArray(typ reflect.Type) (*Spec, error) {
 	return spec, nil
 }
 
+func (g *generator) specForMap(typ reflect.Type) (*Spec, error) {
+	if !isMapKeyKind(typ.Key().Kind()) {
+		return nil, fmt.Errorf("cannot generate spec for type %v: keys must be strings or integers", typ)
+	}
+	spec := &Spec{Type: Object}
+	if typ.Elem().Kind() == reflect.Interface {
+		// any value is accepted
+		return spec, nil
+	}
+	valueSpec, err := g.specForType(typ.Elem())
+	if err != nil {
+		return nil, err
+	}
+	spec.Values = valueSpec
+	return spec, nil
+}
+
+// isMapKeyKind returns true for the kinds of map keys that are supported, which like in
+// encoding/json are strings and integers. Integers are written in decimal in object keys.
+func isMapKeyKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
 // fieldFor generates a [Field] for a field in an object.
-func fieldFor(structField reflect.StructField) (string, *Field, error) {
-	name := translateName(structField.Name)
-	spec, err := specForType(structField.Type)
+func (g *generator) fieldFor(structField reflect.StructField) (string, *Field, error) {
+	name, omitEmpty, _ := fieldKey(structField)
+	spec, err := g.specForType(structField.Type)
 	if err != nil {
 		return "", nil, fmt.Errorf("field %s: %v", name, err)
 	}
 	field := &Field{Spec: *spec}
 	err = parseTag(field, string(structField.Tag))
 	if err != nil {
-		return "", nil, err
+		return "", nil, fmt.Errorf("field %s: %v", name, err)
+	}
+	if field.Required && omitEmpty {
+		// encoding/json could omit the field, which would make the output invalid
+		return "", nil, fmt.Errorf("field %s: a required field can't have the omitempty option", name)
 	}
 	return name, field, nil
 }
 
+// fieldKey returns the key used in JSON objects for a struct field. It follows the json tag of the
+// field like encoding/json, so `json:"name"` sets the key and `json:"-"` skips the field. Fields
+// without a name in the json tag use [translateName]. It also returns whether the tag has the
+// omitempty option. ok is false if the field is skipped, which is also the case for unexported
+// fields.
+func fieldKey(structField reflect.StructField) (key string, omitEmpty, ok bool) {
+	if !structField.IsExported() {
+		return "", false, false
+	}
+	tag := structField.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	if name == "" {
+		name = translateName(structField.Name)
+	}
+	return name, omitEmpty, true
+}
+
 var wordRe = regexp.MustCompile(`[A-Z][a-z]+`)
 
 // translateName translates a name from the convention for Go struct fields to the convention for
@@ -119,7 +501,7 @@ func translateName(name string) string {
 }
 
 // match the first key:"value" pair in a tag
-var tagRe = regexp.MustCompile(`^([a-z]+):("[^"]+")( +.*)?$`)
+var tagRe = regexp.MustCompile(`^([a-z_]+):("(?:[^"\\]|\\.)+")( +.*)?$`)
 
 func parseTag(field *Field, tag string) error {
 	remaining := tag
@@ -135,6 +517,10 @@ func parseTag(field *Field, tag string) error {
 		key := matches[1]
 		quotedValue := matches[2]
 		remaining = matches[3]
+		if key == "json" {
+			// handled by fieldKey
+			continue
+		}
 		value, err := strconv.Unquote(quotedValue)
 		if err != nil {
 			return fmt.Errorf("invalid value: %s", quotedValue)
@@ -158,6 +544,74 @@ func applyTag(field *Field, key, value string) error {
 		field.Required = b
 	case "tags":
 		field.Tags = strings.Split(value, ",")
+	case "additional_fields":
+		if field.Spec.Type != Object {
+			return fmt.Errorf("additional_fields is only valid for objects, not %s", field.Spec.Type)
+		}
+		b, ok := parseBool(value)
+		if !ok {
+			return fmt.Errorf("invalid boolean: %s", value)
+		}
+		field.Spec.AdditionalFields = &b
+	case "min", "max", "exclusive_min", "exclusive_max", "multiple_of":
+		if field.Spec.Type != Integer && field.Spec.Type != Number {
+			return fmt.Errorf("%s is only valid for numbers, not %s", key, field.Spec.Type)
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number: %s", value)
+		}
+		switch key {
+		case "min":
+			field.Spec.Minimum = &n
+		case "max":
+			field.Spec.Maximum = &n
+		case "exclusive_min":
+			field.Spec.ExclusiveMinimum = &n
+		case "exclusive_max":
+			field.Spec.ExclusiveMaximum = &n
+		case "multiple_of":
+			if n <= 0 {
+				return fmt.Errorf("multiple_of must be greater than zero: %s", value)
+			}
+			field.Spec.MultipleOf = &n
+		}
+	case "min_length", "max_length":
+		if field.Spec.Type != String {
+			return fmt.Errorf("%s is only valid for strings, not %s", key, field.Spec.Type)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid length: %s", value)
+		}
+		if key == "min_length" {
+			field.Spec.MinLength = &n
+		} else {
+			field.Spec.MaxLength = &n
+		}
+	case "pattern":
+		if field.Spec.Type != String {
+			return fmt.Errorf("pattern is only valid for strings, not %s", field.Spec.Type)
+		}
+		_, err := compilePattern(value)
+		if err != nil {
+			return err
+		}
+		field.Spec.Pattern = value
+	case "enum":
+		switch field.Spec.Type {
+		case Object, Array:
+			return fmt.Errorf("enum is not valid for %s", field.Spec.Type)
+		}
+		values := strings.Split(value, ",")
+		field.Spec.Enum = make([]any, len(values))
+		for i, v := range values {
+			enumValue, err := parseDefaultValue(field.Spec.Type, v)
+			if err != nil {
+				return err
+			}
+			field.Spec.Enum[i] = enumValue
+		}
 	case "default":
 		defaultValue, err := parseDefaultValue(field.Spec.Type, value)
 		if err != nil {
@@ -2,6 +2,7 @@ package jsonspec
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -23,6 +24,23 @@ func TestSpecFor(t *testing.T) {
 		{"", Spec{Type: String}},
 		{0, Spec{Type: Integer}},
 		{0.0, Spec{Type: Number}},
+		{int64(0), Spec{Type: Integer}},
+		{int8(0), Spec{Type: Integer, Minimum: ptr(-128.0), Maximum: ptr(127.0)}},
+		{int32(0), Spec{Type: Integer, Minimum: ptr(-2147483648.0), Maximum: ptr(2147483647.0)}},
+		{uint8(0), Spec{Type: Integer, Minimum: ptr(0.0), Maximum: ptr(255.0)}},
+		{uint16(0), Spec{Type: Integer, Minimum: ptr(0.0), Maximum: ptr(65535.0)}},
+		{uint64(0), Spec{Type: Integer, Minimum: ptr(0.0)}},
+		{float32(0), Spec{Type: Number}},
+		{new(*string), Spec{Type: String, Nullable: true}},
+		{[]*int{}, Spec{Type: Array, Elements: &Spec{Type: Integer, Nullable: true}}},
+		{
+			struct{ Admin *struct{ Admin bool } }{},
+			Spec{Type: Object, Fields: map[string]Field{"admin": {Spec: Spec{
+				Type:     Object,
+				Nullable: true,
+				Fields:   objectSpec.Fields,
+			}}}},
+		},
 		{time.Time{}, Spec{Type: Datetime}},
 		{object, objectSpec},
 		{&object, objectSpec},
@@ -30,6 +48,12 @@ func TestSpecFor(t *testing.T) {
 		{&array, arraySpec},
 		{mapObj, mapSpec},
 		{&mapObj, mapSpec},
+		{map[string]int{}, Spec{Type: Object, Values: &Spec{Type: Integer}}},
+		{map[int]string{}, Spec{Type: Object, Values: &Spec{Type: String}}},
+		{
+			map[string][]struct{ Admin bool }{},
+			Spec{Type: Object, Values: &Spec{Type: Array, Elements: &objectSpec}},
+		},
 	}
 	for _, c := range cases {
 		got, err := For(c.o)
@@ -55,7 +79,7 @@ func TestSpecForObject(t *testing.T) {
 		},
 	}
 	typ := reflect.TypeOf(object)
-	got, err := specForObject(typ)
+	got, err := newGenerator(typ).specForObject(typ)
 	if err != nil {
 		t.Fatalf("specForObject(%v) returned error: %v", typ, err)
 	}
@@ -95,7 +119,7 @@ func TestSpecForArray(t *testing.T) {
 	}
 	for _, c := range cases {
 		typ := reflect.TypeOf(c.array)
-		got, err := specForArray(typ)
+		got, err := newGenerator(typ).specForArray(typ)
 		if err != nil {
 			t.Errorf("specForArray(%v) returned error: %v", typ, err)
 			continue
@@ -165,10 +189,24 @@ func TestFieldFor(t *testing.T) {
 				},
 			}},
 		},
+		{
+			struct {
+				UserID string `json:"userId" required:"true"`
+			}{},
+			"userId",
+			Field{Spec: Spec{Type: String}, Required: true},
+		},
+		{
+			struct {
+				FirstName string `json:",omitempty"`
+			}{},
+			"first_name",
+			Field{Spec: Spec{Type: String}},
+		},
 	}
 	for _, c := range cases {
 		structField := reflect.TypeOf(c.o).Field(0)
-		name, field, err := fieldFor(structField)
+		name, field, err := newGenerator(structField.Type).fieldFor(structField)
 		if err != nil {
 			t.Errorf("fieldFor(%v) returned error: %v", structField, err)
 			continue
@@ -182,6 +220,224 @@ func TestFieldFor(t *testing.T) {
 	}
 }
 
+func TestFieldForError(t *testing.T) {
+	cases := []any{
+		struct {
+			ID int `json:"id,omitempty" required:"true"`
+		}{},
+		struct {
+			ID int `required:"maybe"`
+		}{},
+		struct {
+			Callback func()
+		}{},
+	}
+	for _, c := range cases {
+		structField := reflect.TypeOf(c).Field(0)
+		_, _, err := newGenerator(structField.Type).fieldFor(structField)
+		if err == nil {
+			t.Errorf("fieldFor(%v) did not return error", structField)
+		}
+	}
+}
+
+func TestSpecForObjectSkipsFields(t *testing.T) {
+	var object struct {
+		Username string `json:"login"`
+		Password string `json:"-"`
+		Dash     string `json:"-,"`
+		internal string
+	}
+	want := &Spec{
+		Type: Object,
+		Fields: map[string]Field{
+			"login": {Spec: Spec{Type: String}},
+			"-":     {Spec: Spec{Type: String}},
+		},
+	}
+	typ := reflect.TypeOf(object)
+	got, err := newGenerator(typ).specForObject(typ)
+	if err != nil {
+		t.Fatalf("specForObject(%v) returned error: %v", typ, err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("specForObject(%v) result mismatch (-want +got):\n%s", typ, diff)
+	}
+	_ = object.internal
+}
+
+func TestSpecForObjectDuplicateKey(t *testing.T) {
+	typ := reflect.TypeOf(struct {
+		Name     string
+		FullName string `json:"name"`
+	}{})
+	_, err := newGenerator(typ).specForObject(typ)
+	if err == nil {
+		t.Errorf("specForObject(%v) did not return error", typ)
+	}
+}
+
+type BaseConnectorArgs struct {
+	Name    string `required:"true"`
+	Timeout int    `default:"30"`
+}
+
+type OAuthCredentials struct {
+	ClientID     string
+	ClientSecret string `tags:"secret"`
+}
+
+type otherCredentials struct {
+	ClientID string
+	Token    string
+}
+
+func TestSpecForObjectEmbedded(t *testing.T) {
+	cases := []struct {
+		o    any
+		want *Spec
+	}{
+		{
+			struct {
+				BaseConnectorArgs
+				*OAuthCredentials
+				Workspace string
+			}{},
+			&Spec{Type: Object, Fields: map[string]Field{
+				"name":          {Spec: Spec{Type: String}, Required: true},
+				"timeout":       {Spec: Spec{Type: Integer}, Default: 30},
+				"client_id":     {Spec: Spec{Type: String}},
+				"client_secret": {Spec: Spec{Type: String}, Tags: []string{"secret"}},
+				"workspace":     {Spec: Spec{Type: String}},
+			}},
+		},
+		{
+			// the field of the outer struct wins over the promoted one
+			struct {
+				BaseConnectorArgs
+				Name string `description:"Overridden"`
+			}{},
+			&Spec{Type: Object, Fields: map[string]Field{
+				"name":    {Spec: Spec{Type: String, Description: "Overridden"}},
+				"timeout": {Spec: Spec{Type: Integer}, Default: 30},
+			}},
+		},
+		{
+			// unexported embedded structs are promoted too
+			struct{ otherCredentials }{},
+			&Spec{Type: Object, Fields: map[string]Field{
+				"client_id": {Spec: Spec{Type: String}},
+				"token":     {Spec: Spec{Type: String}},
+			}},
+		},
+		{
+			// embedded structs with a name in the json tag are not promoted
+			struct {
+				OAuthCredentials `json:"oauth"`
+			}{},
+			&Spec{Type: Object, Fields: map[string]Field{
+				"oauth": {Spec: Spec{Type: Object, Fields: map[string]Field{
+					"client_id":     {Spec: Spec{Type: String}},
+					"client_secret": {Spec: Spec{Type: String}, Tags: []string{"secret"}},
+				}}},
+			}},
+		},
+	}
+	for _, c := range cases {
+		typ := reflect.TypeOf(c.o)
+		got, err := newGenerator(typ).specForObject(typ)
+		if err != nil {
+			t.Errorf("specForObject(%v) returned error: %v", typ, err)
+			continue
+		}
+		if diff := cmp.Diff(c.want, got); diff != "" {
+			t.Errorf("specForObject(%v) result mismatch (-want +got):\n%s", typ, diff)
+		}
+	}
+}
+
+func TestSpecForObjectEmbeddedConflict(t *testing.T) {
+	typ := reflect.TypeOf(struct {
+		OAuthCredentials
+		otherCredentials
+	}{})
+	_, err := newGenerator(typ).specForObject(typ)
+	if err == nil {
+		t.Fatalf("specForObject(%v) did not return error", typ)
+	}
+	want := `conflicting fields for key "client_id"`
+	if got := err.Error(); !strings.Contains(got, want) || !strings.Contains(got, "OAuthCredentials.ClientID, otherCredentials.ClientID") {
+		t.Errorf("specForObject(%v) returned %q, want it to contain %q and both field names", typ, got, want)
+	}
+}
+
+type Node struct {
+	Name     string `required:"true"`
+	Children []Node
+	Parent   *Node
+}
+
+type Address struct {
+	Street string
+}
+
+func TestSpecForDefinitions(t *testing.T) {
+	nodeSpec := &Spec{Type: Object, Fields: map[string]Field{
+		"name":     {Spec: Spec{Type: String}, Required: true},
+		"children": {Spec: Spec{Type: Array, Elements: &Spec{Type: Object, Ref: "Node"}}},
+		"parent":   {Spec: Spec{Type: Object, Ref: "Node", Nullable: true}},
+	}}
+	addressSpec := &Spec{Type: Object, Fields: map[string]Field{
+		"street": {Spec: Spec{Type: String}},
+	}}
+	cases := []struct {
+		o    any
+		want Spec
+	}{
+		{
+			Node{},
+			Spec{Type: Object, Ref: "Node", Definitions: map[string]*Spec{"Node": nodeSpec}},
+		},
+		{
+			[]Node{},
+			Spec{
+				Type:        Array,
+				Elements:    &Spec{Type: Object, Ref: "Node"},
+				Definitions: map[string]*Spec{"Node": nodeSpec},
+			},
+		},
+		{
+			struct {
+				Home Address `description:"Where they live"`
+				Work *Address
+			}{},
+			Spec{
+				Type: Object,
+				Fields: map[string]Field{
+					"home": {Spec: Spec{Type: Object, Ref: "Address", Description: "Where they live"}},
+					"work": {Spec: Spec{Type: Object, Ref: "Address", Nullable: true}},
+				},
+				Definitions: map[string]*Spec{"Address": addressSpec},
+			},
+		},
+		{
+			// types used only once are not turned into definitions
+			struct{ Home Address }{},
+			Spec{Type: Object, Fields: map[string]Field{"home": {Spec: *addressSpec}}},
+		},
+	}
+	for _, c := range cases {
+		got, err := For(c.o)
+		if err != nil {
+			t.Errorf("For(%v) returned error: %v", c.o, err)
+			continue
+		}
+		if diff := cmp.Diff(c.want, *got); diff != "" {
+			t.Errorf("For(%v) result mismatch (-want +got):\n%s", c.o, diff)
+		}
+	}
+}
+
 func TestTranslateName(t *testing.T) {
 	cases := []struct {
 		name, want string
@@ -229,6 +485,10 @@ func TestParseTag(t *testing.T) {
 			`required:"true"     tags:"secret"`,
 			&Field{Required: true, Tags: []string{"secret"}},
 		},
+		{
+			`description:"Say \"hi\""`,
+			&Field{Spec: Spec{Description: `Say "hi"`}},
+		},
 	}
 	for _, c := range cases {
 		field := new(Field)
@@ -272,6 +532,60 @@ func TestApplyTag(t *testing.T) {
 			&Field{Spec: Spec{Type: Integer}},
 			&Field{Spec: Spec{Type: Integer}, Default: 123},
 		},
+		{
+			"min",
+			"1",
+			&Field{Spec: Spec{Type: Integer}},
+			&Field{Spec: Spec{Type: Integer, Minimum: ptr(1.0)}},
+		},
+		{
+			"exclusive_max",
+			"0.5",
+			&Field{Spec: Spec{Type: Number}},
+			&Field{Spec: Spec{Type: Number, ExclusiveMaximum: ptr(0.5)}},
+		},
+		{
+			"multiple_of",
+			"5",
+			&Field{Spec: Spec{Type: Integer}},
+			&Field{Spec: Spec{Type: Integer, MultipleOf: ptr(5.0)}},
+		},
+		{
+			"min_length",
+			"3",
+			&Field{Spec: Spec{Type: String}},
+			&Field{Spec: Spec{Type: String, MinLength: ptr(3)}},
+		},
+		{
+			"max_length",
+			"8",
+			&Field{Spec: Spec{Type: String}},
+			&Field{Spec: Spec{Type: String, MaxLength: ptr(8)}},
+		},
+		{
+			"pattern",
+			`^\d+$`,
+			&Field{Spec: Spec{Type: String}},
+			&Field{Spec: Spec{Type: String, Pattern: `^\d+$`}},
+		},
+		{
+			"enum",
+			"us,eu,ap",
+			&Field{Spec: Spec{Type: String}},
+			&Field{Spec: Spec{Type: String, Enum: []any{"us", "eu", "ap"}}},
+		},
+		{
+			"enum",
+			"1,2,3",
+			&Field{Spec: Spec{Type: Integer}},
+			&Field{Spec: Spec{Type: Integer, Enum: []any{1, 2, 3}}},
+		},
+		{
+			"additional_fields",
+			"false",
+			&Field{Spec: Spec{Type: Object}},
+			&Field{Spec: Spec{Type: Object, AdditionalFields: new(bool)}},
+		},
 	}
 	for _, c := range cases {
 		err := applyTag(c.field, c.key, c.value)
@@ -297,6 +611,51 @@ func TestApplyTagError(t *testing.T) {
 			"default",
 			"hello",
 		},
+		{
+			&Field{Spec: Spec{Type: String}},
+			"additional_fields",
+			"false",
+		},
+		{
+			&Field{Spec: Spec{Type: String}},
+			"min",
+			"1",
+		},
+		{
+			&Field{Spec: Spec{Type: Integer}},
+			"max",
+			"many",
+		},
+		{
+			&Field{Spec: Spec{Type: Integer}},
+			"multiple_of",
+			"0",
+		},
+		{
+			&Field{Spec: Spec{Type: String}},
+			"max_length",
+			"-1",
+		},
+		{
+			&Field{Spec: Spec{Type: Integer}},
+			"pattern",
+			"^a$",
+		},
+		{
+			&Field{Spec: Spec{Type: String}},
+			"pattern",
+			"(unclosed",
+		},
+		{
+			&Field{Spec: Spec{Type: Integer}},
+			"enum",
+			"1,two",
+		},
+		{
+			&Field{Spec: Spec{Type: Array, Elements: &Spec{Type: String}}},
+			"enum",
+			"a,b",
+		},
 	}
 	for _, c := range cases {
 		err := applyTag(c.field, c.key, c.value)
@@ -329,3 +688,128 @@ func TestParseDefaultValue(t *testing.T) {
 		}
 	}
 }
+
+type Region string
+
+func (Region) EnumValues() []any {
+	return []any{Region("us"), Region("eu"), Region("ap")}
+}
+
+type Priority int
+
+func (*Priority) EnumValues() []any {
+	return []any{Priority(1), Priority(2), Priority(3)}
+}
+
+func TestSpecForEnumerator(t *testing.T) {
+	cases := []struct {
+		o    any
+		want Spec
+	}{
+		{Region(""), Spec{Type: String, Enum: []any{"us", "eu", "ap"}}},
+		{Priority(0), Spec{Type: Integer, Enum: []any{1, 2, 3}}},
+		{[]Region{}, Spec{Type: Array, Elements: &Spec{Type: String, Enum: []any{"us", "eu", "ap"}}}},
+		{
+			// EnumValues is promoted from the embedded Region, but the struct isn't an enum
+			struct {
+				Region
+				Name string
+			}{},
+			Spec{Type: Object, Fields: map[string]Field{
+				"region": {Spec: Spec{Type: String, Enum: []any{"us", "eu", "ap"}}},
+				"name":   {Spec: Spec{Type: String}},
+			}},
+		},
+	}
+	for _, c := range cases {
+		got, err := For(c.o)
+		if err != nil {
+			t.Errorf("For(%v) returned error: %v", c.o, err)
+			continue
+		}
+		if diff := cmp.Diff(c.want, *got); diff != "" {
+			t.Errorf("For(%v) result mismatch (-want +got):\n%s", c.o, diff)
+		}
+	}
+}
+
+type ZendeskArgs struct {
+	AccessToken string
+}
+
+func (ZendeskArgs) StrictFields() bool {
+	return true
+}
+
+type StrictNode struct {
+	Children []StrictNode
+}
+
+func (*StrictNode) StrictFields() bool {
+	return true
+}
+
+func TestSpecForStrict(t *testing.T) {
+	cases := []struct {
+		o    any
+		want Spec
+	}{
+		{
+			ZendeskArgs{},
+			Spec{Type: Object, AdditionalFields: ptr(false), Fields: map[string]Field{
+				"access_token": {Spec: Spec{Type: String}},
+			}},
+		},
+		{
+			// StrictFields is promoted from the embedded struct
+			struct {
+				ZendeskArgs
+				Subdomain string
+			}{},
+			Spec{Type: Object, AdditionalFields: ptr(false), Fields: map[string]Field{
+				"access_token": {Spec: Spec{Type: String}},
+				"subdomain":    {Spec: Spec{Type: String}},
+			}},
+		},
+		{
+			StrictNode{},
+			Spec{Type: Object, Ref: "StrictNode", Definitions: map[string]*Spec{
+				"StrictNode": {Type: Object, AdditionalFields: ptr(false), Fields: map[string]Field{
+					"children": {Spec: Spec{Type: Array, Elements: &Spec{Type: Object, Ref: "StrictNode"}}},
+				}},
+			}},
+		},
+	}
+	for _, c := range cases {
+		got, err := For(c.o)
+		if err != nil {
+			t.Errorf("For(%T) returned error: %v", c.o, err)
+			continue
+		}
+		if diff := cmp.Diff(c.want, *got); diff != "" {
+			t.Errorf("For(%T) result mismatch (-want +got):\n%s", c.o, diff)
+		}
+	}
+}
+
+func TestSpecForMapError(t *testing.T) {
+	o := map[float64]string{}
+	_, err := For(o)
+	if err == nil {
+		t.Errorf("For(%v) did not return error", o)
+	}
+}
+
+func TestForInvalidPattern(t *testing.T) {
+	o := struct {
+		Subdomain string `pattern:"([a-z]+"`
+	}{}
+	_, err := For(o)
+	if err == nil {
+		t.Errorf("For(%v) did not return error", o)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
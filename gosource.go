@@ -0,0 +1,466 @@
+package jsonspec
+
+import (
+	"fmt"
+	"go/format"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// GoSource generates Go source code for the package [pkg] with a type named [name] for [spec], so
+// that [For] returns the same spec for the type. Objects become structs with tags like
+// `required:"true"` for the properties of their fields, and definitions become types named after
+// them. Nested objects become struct types named after the type and field they belong to. Struct
+// types for objects that don't accept additional fields implement [Strict].
+//
+// Parts of a spec that can't be expressed with Go types and struct tags cause an error, for example
+// constraints on the elements of arrays other than the range of an integer type. The description of
+// the root spec is kept as the doc comment of the type, which For ignores. For only creates
+// definitions for types that are recursive or used more than once, so other definitions are not
+// reproduced as such.
+func GoSource(pkg, name string, spec *Spec) ([]byte, error) {
+	if !isGoIdentifier(pkg) || !isGoIdentifier(name) {
+		return nil, fmt.Errorf("invalid package or type name: %s, %s", pkg, name)
+	}
+	g := &goWriter{types: make(map[string]bool), definitions: spec.Definitions}
+	for defName := range spec.Definitions {
+		if !isGoIdentifier(defName) {
+			return nil, fmt.Errorf("definition name %q is not a valid Go identifier", defName)
+		}
+		g.types[defName] = true
+	}
+	if spec.Ref != name {
+		if g.types[name] {
+			return nil, fmt.Errorf("type name %s conflicts with a definition", name)
+		}
+		g.types[name] = true
+		if err := g.declare(name, spec); err != nil {
+			return nil, err
+		}
+	}
+	for _, defName := range sortedKeys(spec.Definitions) {
+		if err := g.declare(defName, spec.Definitions[defName]); err != nil {
+			return nil, err
+		}
+	}
+
+	var src strings.Builder
+	src.WriteString("// Code generated by jsonspec-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", pkg)
+	if g.usesTime {
+		src.WriteString("import \"time\"\n\n")
+	}
+	for _, declaration := range g.declarations {
+		src.WriteString(declaration)
+		src.WriteString("\n")
+	}
+	return format.Source([]byte(src.String()))
+}
+
+// A goWriter generates Go type declarations for specs.
+type goWriter struct {
+	// types contains the names of the declared types.
+	types map[string]bool
+
+	definitions  map[string]*Spec
+	declarations []string
+	usesTime     bool
+}
+
+// declare adds the declaration of the type [name] for [spec].
+func (g *goWriter) declare(name string, spec *Spec) error {
+	var b strings.Builder
+	if spec.Description != "" {
+		for _, line := range strings.Split(spec.Description, "\n") {
+			fmt.Fprintf(&b, "// %s\n", line)
+		}
+	}
+	if spec.Nullable || len(spec.Enum) > 0 || (spec.AdditionalFields != nil && !isStrictStruct(spec)) {
+		return fmt.Errorf("%s: nullable, enum and additional_fields can't be set on the root spec", name)
+	}
+	if spec.Ref != "" {
+		// an alias has the name of the type it refers to
+		if _, ok := g.definitions[spec.Ref]; !ok {
+			return fmt.Errorf("%s: undefined reference %q", name, spec.Ref)
+		}
+		fmt.Fprintf(&b, "type %s = %s\n", name, spec.Ref)
+		g.declarations = append(g.declarations, b.String())
+		return nil
+	}
+	// nested types are declared after this one
+	i := len(g.declarations)
+	g.declarations = append(g.declarations, "")
+	var typ string
+	var err error
+	if spec.Type == Object && len(spec.Fields) > 0 {
+		typ, err = g.structType(name, spec)
+	} else {
+		// the description is the doc comment of the type
+		element := *spec
+		element.Description = ""
+		typ, err = g.elementType(name, &element, path{})
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&b, "type %s %s\n", name, typ)
+	b.WriteString(strictMethod(name, spec))
+	g.declarations[i] = b.String()
+	return nil
+}
+
+// isStrictStruct returns true if [spec] is declared as a struct type that implements [Strict].
+func isStrictStruct(spec *Spec) bool {
+	return spec.Ref == "" && spec.Type == Object && len(spec.Fields) > 0 &&
+		spec.AdditionalFields != nil && !*spec.AdditionalFields
+}
+
+// strictMethod returns the StrictFields method for the struct type [name] if [spec] doesn't accept
+// additional fields.
+func strictMethod(name string, spec *Spec) string {
+	if !isStrictStruct(spec) {
+		return ""
+	}
+	return fmt.Sprintf("\nfunc (%s) StrictFields() bool {\nreturn true\n}\n", name)
+}
+
+// structType returns a struct type for the object [spec], which belongs to the type [name].
+func (g *goWriter) structType(name string, spec *Spec) (string, error) {
+	if spec.Values != nil {
+		return "", fmt.Errorf("%s: objects with both fields and values can't be represented", name)
+	}
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	used := make(map[string]bool)
+	for _, key := range sortedKeys(spec.Fields) {
+		field := spec.Fields[key]
+		fieldName := g.fieldName(key, used)
+		typ, err := g.fieldType(name+fieldName, &field.Spec)
+		if err != nil {
+			return "", fmt.Errorf("%s.%s: %v", name, fieldName, err)
+		}
+		tag, err := fieldTag(fieldName, key, &field)
+		if err != nil {
+			return "", fmt.Errorf("%s.%s: %v", name, fieldName, err)
+		}
+		fmt.Fprintf(&b, "%s %s", fieldName, typ)
+		if tag != "" {
+			b.WriteString(" " + tag)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
+
+// fieldType returns the type of a struct field with the spec [spec]. [name] is the name for a
+// struct type if the field is an object.
+func (g *goWriter) fieldType(name string, spec *Spec) (string, error) {
+	prefix := ""
+	if spec.Nullable {
+		prefix = "*"
+	}
+	switch {
+	case spec.Ref != "":
+		if _, ok := g.definitions[spec.Ref]; !ok {
+			return "", fmt.Errorf("undefined reference %q", spec.Ref)
+		}
+		return prefix + spec.Ref, nil
+	case spec.Type == Object && len(spec.Fields) > 0:
+		// declare a named type, which For inlines because it is only used once
+		base := name
+		for i := 2; g.types[name]; i++ {
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+		g.types[name] = true
+		i := len(g.declarations)
+		g.declarations = append(g.declarations, "")
+		structSpec := &Spec{Type: Object, Fields: spec.Fields, Values: spec.Values, AdditionalFields: spec.AdditionalFields}
+		typ, err := g.structType(name, structSpec)
+		if err != nil {
+			return "", err
+		}
+		g.declarations[i] = fmt.Sprintf("type %s %s\n", name, typ) + strictMethod(name, structSpec)
+		return prefix + name, nil
+	case spec.Type == Integer:
+		if typ, ok := sizedIntegerType(spec); ok {
+			return prefix + typ, nil
+		}
+		// the range of the spec is set with tags
+		return prefix + "int", nil
+	}
+	typ, err := g.elementType(name, &Spec{Type: spec.Type, Elements: spec.Elements, Values: spec.Values}, path{})
+	if err != nil {
+		return "", err
+	}
+	return prefix + typ, nil
+}
+
+// elementType returns the Go type for the spec of a value that can't have tags, like the element
+// of an array. [name] is the name for a struct type if the value is an object.
+func (g *goWriter) elementType(name string, spec *Spec, path path) (string, error) {
+	prefix := ""
+	if spec.Nullable {
+		prefix = "*"
+	}
+	if spec.Description != "" || len(spec.Enum) > 0 || (spec.AdditionalFields != nil && !isStrictStruct(spec)) ||
+		spec.ExclusiveMinimum != nil || spec.ExclusiveMaximum != nil || spec.MultipleOf != nil ||
+		spec.MinLength != nil || spec.MaxLength != nil || spec.Pattern != "" {
+		return "", fmt.Errorf("%sconstraints and descriptions of elements and values can't be represented", path.prefix())
+	}
+	if spec.Ref != "" {
+		return g.fieldType(name, spec)
+	}
+	switch spec.Type {
+	case Boolean:
+		return prefix + "bool", nil
+	case String:
+		return prefix + "string", nil
+	case Datetime:
+		g.usesTime = true
+		return prefix + "time.Time", nil
+	case Number:
+		if spec.Minimum != nil || spec.Maximum != nil {
+			break
+		}
+		return prefix + "float64", nil
+	case Integer:
+		typ, ok := integerType(spec.Minimum, spec.Maximum)
+		if !ok {
+			break
+		}
+		return prefix + typ, nil
+	case Array:
+		if spec.Elements == nil {
+			return prefix + "[]any", nil
+		}
+		typ, err := g.elementType(name+"Element", spec.Elements, path.append(0))
+		if err != nil {
+			return "", err
+		}
+		return prefix + "[]" + typ, nil
+	case Object:
+		if len(spec.Fields) > 0 {
+			return g.fieldType(name, &Spec{
+				Type: Object, Fields: spec.Fields, Values: spec.Values, Nullable: spec.Nullable, AdditionalFields: spec.AdditionalFields,
+			})
+		}
+		if spec.Values == nil {
+			return prefix + "map[string]any", nil
+		}
+		typ, err := g.elementType(name+"Value", spec.Values, path.append("*"))
+		if err != nil {
+			return "", err
+		}
+		return prefix + "map[string]" + typ, nil
+	default:
+		return "", fmt.Errorf("%sunknown type %q", path.prefix(), spec.Type)
+	}
+	return "", fmt.Errorf("%sthe range of this %s can't be represented", path.prefix(), spec.Type)
+}
+
+// integerType returns the Go integer type whose range is exactly [minimum, maximum], as recorded
+// by [For].
+func integerType(minimum, maximum *float64) (string, bool) {
+	if minimum == nil && maximum == nil {
+		return "int", true
+	}
+	if minimum == nil {
+		return "", false
+	}
+	if maximum == nil {
+		return "uint64", *minimum == 0
+	}
+	ranges := []struct {
+		typ      string
+		min, max float64
+	}{
+		{"int8", math.MinInt8, math.MaxInt8},
+		{"int16", math.MinInt16, math.MaxInt16},
+		{"int32", math.MinInt32, math.MaxInt32},
+		{"uint8", 0, math.MaxUint8},
+		{"uint16", 0, math.MaxUint16},
+		{"uint32", 0, math.MaxUint32},
+	}
+	for _, r := range ranges {
+		if *minimum == r.min && *maximum == r.max {
+			return r.typ, true
+		}
+	}
+	return "", false
+}
+
+// sizedIntegerType returns the Go integer type for an integer field if its range is exactly the
+// range of a sized integer type, like uint8.
+func sizedIntegerType(spec *Spec) (string, bool) {
+	if spec.Type != Integer || spec.Ref != "" || spec.Minimum == nil || spec.Maximum == nil {
+		return "", false
+	}
+	return integerType(spec.Minimum, spec.Maximum)
+}
+
+// fieldName returns an exported Go name for the field with the key [key], which isn't in [used].
+func (g *goWriter) fieldName(key string, used map[string]bool) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, word := range words {
+		if initialism := strings.ToUpper(word); commonInitialisms[initialism] {
+			b.WriteString(initialism)
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	name := b.String()
+	if name == "" || !unicode.IsUpper([]rune(name)[0]) {
+		name = "F" + name
+	}
+	base := name
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	used[name] = true
+	return name
+}
+
+// commonInitialisms are written in upper case in Go names.
+var commonInitialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true,
+	"SQL": true, "SSH": true, "TLS": true, "TTL": true, "UI": true, "URI": true, "URL": true,
+	"UUID": true, "XML": true,
+}
+
+// fieldTag returns the struct tag for a field with the Go name [fieldName] and the key [key].
+func fieldTag(fieldName, key string, field *Field) (string, error) {
+	var tags []string
+	var empty []string
+	add := func(key, value string) {
+		if value == "" {
+			// tags with empty values are ignored
+			empty = append(empty, key)
+			return
+		}
+		tags = append(tags, key+":"+strconv.Quote(value))
+	}
+	if translateName(fieldName) != key {
+		switch {
+		case key == "-":
+			add("json", "-,")
+		case strings.Contains(key, ","):
+			return "", fmt.Errorf("keys with commas can't be represented: %q", key)
+		default:
+			add("json", key)
+		}
+	}
+	if field.Required {
+		add("required", "true")
+	}
+	if field.Description != "" {
+		add("description", field.Description)
+	}
+	if field.Default != nil {
+		value, err := tagValue(field.Type, field.Default)
+		if err != nil {
+			return "", fmt.Errorf("default: %v", err)
+		}
+		add("default", value)
+	}
+	if len(field.Tags) > 0 {
+		add("tags", strings.Join(field.Tags, ","))
+	}
+	if len(field.Enum) > 0 {
+		values := make([]string, len(field.Enum))
+		for i, v := range field.Enum {
+			value, err := tagValue(field.Type, v)
+			if err != nil {
+				return "", fmt.Errorf("enum: %v", err)
+			}
+			if strings.Contains(value, ",") {
+				return "", fmt.Errorf("enum values with commas can't be represented: %q", value)
+			}
+			values[i] = value
+		}
+		add("enum", strings.Join(values, ","))
+	}
+	if field.AdditionalFields != nil && !isStrictStruct(&field.Spec) {
+		// struct types implement Strict instead
+		add("additional_fields", strconv.FormatBool(*field.AdditionalFields))
+	}
+	if _, ok := sizedIntegerType(&field.Spec); !ok && (field.Minimum != nil || field.Maximum != nil) {
+		if field.Ref != "" || (field.Type != Integer && field.Type != Number) {
+			return "", fmt.Errorf("a range can't be set for %s", field.Type)
+		}
+		addNumber(add, "min", field.Minimum)
+		addNumber(add, "max", field.Maximum)
+	}
+	addNumber(add, "exclusive_min", field.ExclusiveMinimum)
+	addNumber(add, "exclusive_max", field.ExclusiveMaximum)
+	addNumber(add, "multiple_of", field.MultipleOf)
+	if field.MinLength != nil {
+		add("min_length", strconv.Itoa(*field.MinLength))
+	}
+	if field.MaxLength != nil {
+		add("max_length", strconv.Itoa(*field.MaxLength))
+	}
+	if field.Pattern != "" {
+		add("pattern", field.Pattern)
+	}
+	if len(empty) > 0 {
+		return "", fmt.Errorf("empty values can't be represented: %s", strings.Join(empty, ", "))
+	}
+	if len(tags) == 0 {
+		return "", nil
+	}
+	tag := strings.Join(tags, " ")
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag), nil
+	}
+	return "`" + tag + "`", nil
+}
+
+func addNumber(add func(key, value string), key string, n *float64) {
+	if n != nil {
+		add(key, strconv.FormatFloat(*n, 'g', -1, 64))
+	}
+}
+
+// tagValue formats a default or enum value for a struct tag, so that parseDefaultValue returns
+// the same value.
+func tagValue(typ Type, value any) (string, error) {
+	switch typ {
+	case Boolean, String:
+		return fmt.Sprint(value), nil
+	case Integer, Number:
+		n, ok := toNumber(value)
+		if !ok {
+			return "", fmt.Errorf("expected a number: %v", value)
+		}
+		if typ == Integer && n != math.Trunc(n) {
+			return "", fmt.Errorf("expected an integer: %v", value)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case Datetime:
+		t, ok := toTime(value)
+		if !ok {
+			return "", fmt.Errorf("expected a datetime: %v", value)
+		}
+		return t.Format(time.RFC3339Nano), nil
+	}
+	return "", fmt.Errorf("can't be set for %s", typ)
+}
+
+// isGoIdentifier returns true if [s] is a valid Go identifier.
+func isGoIdentifier(s string) bool {
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
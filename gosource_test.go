@@ -0,0 +1,260 @@
+package jsonspec
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// Connector, ConnectorOwner and TreeNode are the types generated by GoSource for connectorSpec.
+
+// Settings of a connector
+type Connector struct {
+	APIKey    string `tags:"secret" pattern:"^[a-z]+$"`
+	CreatedAt *time.Time
+	Extra     map[string]any
+	FirstName string `json:"first-name"`
+	Labels    map[string]string
+	Name      string `required:"true" description:"Name of the \"connector\"" min_length:"1"`
+	Nodes     []TreeNode
+	Owner     *ConnectorOwner
+	Ratio     float64 `min:"0" max:"255"`
+	Region    string  `default:"us" enum:"us,eu"`
+	Retries   uint8
+	Timeout   int `default:"30" min:"1" max:"3600"`
+	Weights   []uint8
+}
+
+type ConnectorOwner struct {
+	Email string `required:"true"`
+}
+
+func (ConnectorOwner) StrictFields() bool {
+	return true
+}
+
+type TreeNode struct {
+	Children []TreeNode
+	Score    float64 `exclusive_min:"0"`
+}
+
+const connectorSpec = `{
+	"type": "object",
+	"description": "Settings of a connector",
+	"fields": {
+		"name": {"type": "string", "required": true, "description": "Name of the \"connector\"", "min_length": 1},
+		"timeout": {"type": "integer", "default": 30, "minimum": 1, "maximum": 3600},
+		"retries": {"type": "integer", "minimum": 0, "maximum": 255},
+		"ratio": {"type": "number", "minimum": 0, "maximum": 255},
+		"weights": {"type": "array", "elements": {"type": "integer", "minimum": 0, "maximum": 255}},
+		"api_key": {"type": "string", "tags": ["secret"], "pattern": "^[a-z]+$"},
+		"first-name": {"type": "string"},
+		"region": {"type": "string", "enum": ["us", "eu"], "default": "us"},
+		"created_at": {"type": "datetime", "nullable": true},
+		"labels": {"type": "object", "values": {"type": "string"}},
+		"extra": {"type": "object"},
+		"owner": {"type": "object", "nullable": true, "additional_fields": false, "fields": {
+			"email": {"type": "string", "required": true}
+		}},
+		"nodes": {"type": "array", "elements": {"type": "object", "ref": "TreeNode"}}
+	},
+	"definitions": {
+		"TreeNode": {"type": "object", "fields": {
+			"children": {"type": "array", "elements": {"type": "object", "ref": "TreeNode"}},
+			"score": {"type": "number", "exclusive_minimum": 0}
+		}}
+	}
+}`
+
+func TestGoSource(t *testing.T) {
+	var spec Spec
+	if err := json.Unmarshal([]byte(connectorSpec), &spec); err != nil {
+		t.Fatal(err)
+	}
+	src, err := GoSource("connectors", "Connector", &spec)
+	if err != nil {
+		t.Fatalf("GoSource returned error: %v", err)
+	}
+	want := "// Code generated by jsonspec-gen. DO NOT EDIT.\n" + `
+package connectors
+
+import "time"
+
+// Settings of a connector
+type Connector struct {
+	APIKey    string ` + "`" + `tags:"secret" pattern:"^[a-z]+$"` + "`" + `
+	CreatedAt *time.Time
+	Extra     map[string]any
+	FirstName string ` + "`" + `json:"first-name"` + "`" + `
+	Labels    map[string]string
+	Name      string ` + "`" + `required:"true" description:"Name of the \"connector\"" min_length:"1"` + "`" + `
+	Nodes     []TreeNode
+	Owner     *ConnectorOwner
+	Ratio     float64 ` + "`" + `min:"0" max:"255"` + "`" + `
+	Region    string  ` + "`" + `default:"us" enum:"us,eu"` + "`" + `
+	Retries   uint8
+	Timeout   int ` + "`" + `default:"30" min:"1" max:"3600"` + "`" + `
+	Weights   []uint8
+}
+
+type ConnectorOwner struct {
+	Email string ` + "`" + `required:"true"` + "`" + `
+}
+
+func (ConnectorOwner) StrictFields() bool {
+	return true
+}
+
+type TreeNode struct {
+	Children []TreeNode
+	Score    float64 ` + "`" + `exclusive_min:"0"` + "`" + `
+}
+`
+	if diff := cmp.Diff(want, string(src)); diff != "" {
+		t.Errorf("GoSource result mismatch (-want +got):\n%s", diff)
+	}
+
+	// the generated types must have the same spec, except for the description of the root
+	generated, err := For(Connector{})
+	if err != nil {
+		t.Fatalf("For returned error: %v", err)
+	}
+	spec.Description = ""
+	if diff := cmp.Diff(specAsJSON(t, &spec), specAsJSON(t, generated)); diff != "" {
+		t.Errorf("For(generated type) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// Payload, PayloadContactsElement and PayloadOwner are the types generated by GoSource for the
+// spec inferred in TestGoSourceInferred.
+
+type Payload struct {
+	Contacts  []PayloadContactsElement `required:"true"`
+	CreatedAt time.Time                `required:"true"`
+	ID        int                      `required:"true"`
+	Owner     PayloadOwner             `required:"true"`
+	Score     float64
+	Tags      []string `required:"true"`
+}
+
+func (Payload) StrictFields() bool {
+	return true
+}
+
+type PayloadContactsElement struct {
+	Name  string  `required:"true"`
+	Phone *string `required:"true"`
+}
+
+func (PayloadContactsElement) StrictFields() bool {
+	return true
+}
+
+type PayloadOwner struct {
+	Email string `required:"true"`
+}
+
+func (PayloadOwner) StrictFields() bool {
+	return true
+}
+
+func TestGoSourceInferred(t *testing.T) {
+	// Infer doesn't accept additional fields in any object
+	spec, err := InferJSON([]byte(`
+		{"id": 1, "created_at": "2024-03-07T11:38:47Z", "owner": {"email": "a@example.com"}, "tags": ["a"], "contacts": [{"name": "Jane", "phone": null}]}
+		{"id": 2, "created_at": "2024-03-08T09:12:00Z", "owner": {"email": "b@example.com"}, "tags": [], "contacts": [{"name": "John", "phone": "123"}], "score": 0.5}
+	`))
+	if err != nil {
+		t.Fatalf("InferJSON returned error: %v", err)
+	}
+	src, err := GoSource("payloads", "Payload", spec)
+	if err != nil {
+		t.Fatalf("GoSource returned error: %v", err)
+	}
+	want := "// Code generated by jsonspec-gen. DO NOT EDIT.\n" + `
+package payloads
+
+import "time"
+
+type Payload struct {
+	Contacts  []PayloadContactsElement ` + "`" + `required:"true"` + "`" + `
+	CreatedAt time.Time                ` + "`" + `required:"true"` + "`" + `
+	ID        int                      ` + "`" + `required:"true"` + "`" + `
+	Owner     PayloadOwner             ` + "`" + `required:"true"` + "`" + `
+	Score     float64
+	Tags      []string ` + "`" + `required:"true"` + "`" + `
+}
+
+func (Payload) StrictFields() bool {
+	return true
+}
+
+type PayloadContactsElement struct {
+	Name  string  ` + "`" + `required:"true"` + "`" + `
+	Phone *string ` + "`" + `required:"true"` + "`" + `
+}
+
+func (PayloadContactsElement) StrictFields() bool {
+	return true
+}
+
+type PayloadOwner struct {
+	Email string ` + "`" + `required:"true"` + "`" + `
+}
+
+func (PayloadOwner) StrictFields() bool {
+	return true
+}
+`
+	if diff := cmp.Diff(want, string(src)); diff != "" {
+		t.Errorf("GoSource result mismatch (-want +got):\n%s", diff)
+	}
+
+	generated, err := For(Payload{})
+	if err != nil {
+		t.Fatalf("For returned error: %v", err)
+	}
+	if diff := cmp.Diff(specAsJSON(t, spec), specAsJSON(t, generated)); diff != "" {
+		t.Errorf("For(generated type) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// specAsJSON returns the JSON form of [spec], so that specs can be compared regardless of the Go
+// types of their values, like int and float64 defaults.
+func specAsJSON(t *testing.T, spec *Spec) any {
+	t.Helper()
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result any
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestGoSourceError(t *testing.T) {
+	cases := []*Spec{
+		{Type: Object, Nullable: true, Fields: map[string]Field{"a": {Spec: Spec{Type: String}}}},
+		{Type: Array, Elements: &Spec{Type: String, MinLength: ptr(1)}},
+		{Type: Array, Elements: &Spec{Type: Integer, Minimum: ptr(1.0), Maximum: ptr(10.0)}},
+		{Type: Object, Values: &Spec{Type: String}, Fields: map[string]Field{"a": {Spec: Spec{Type: String}}}},
+		{Type: Object, Fields: map[string]Field{"a,b": {Spec: Spec{Type: String}}}},
+		{Type: Object, Fields: map[string]Field{"a": {Spec: Spec{Type: String}, Default: ""}}},
+		{Type: Object, Fields: map[string]Field{"a": {Spec: Spec{Type: String, Enum: []any{"x,y"}}}}},
+		{Type: Object, Fields: map[string]Field{"a": {Spec: Spec{Type: Object, Ref: "Missing"}}}},
+		{Type: Object, Fields: map[string]Field{"a": {Spec: Spec{Type: Integer}, Default: 1.5}}},
+		{Type: String, Definitions: map[string]*Spec{"not-an-identifier": {Type: String}}},
+		{Type: Object, AdditionalFields: ptr(true), Fields: map[string]Field{"a": {Spec: Spec{Type: String}}}},
+		{Type: Array, Elements: &Spec{Type: Object, AdditionalFields: ptr(false)}},
+	}
+	for _, spec := range cases {
+		_, err := GoSource("specs", "Spec", spec)
+		if err == nil {
+			t.Errorf("GoSource(%v) did not return error", spec)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package jsonspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// Infer returns the tightest spec that accepts all the [samples], which are values decoded from
+// JSON by encoding/json, with or without the UseNumber option.
+//
+// Keys present in every sample of an object are required, and objects don't accept other keys.
+// Strings are datetimes if all of them are in RFC 3339 format, and numbers are integers if all of
+// them are. The elements of all arrays at the same place are merged into one spec. Values that are
+// always null are inferred as nullable strings, because a spec can't accept null only.
+func Infer(samples ...any) (*Spec, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("no samples")
+	}
+	var spec *Spec
+	for i, sample := range samples {
+		s, err := inferValue(sample, path{})
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %v", i, err)
+		}
+		if spec == nil {
+			spec = s
+			continue
+		}
+		spec, err = mergeInferred(spec, s, path{})
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %v", i, err)
+		}
+	}
+	finishInferred(spec)
+	return spec, nil
+}
+
+// InferJSON is like [Infer], with samples from a stream of JSON documents, like a JSON Lines file.
+func InferJSON(data []byte) (*Spec, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var samples []any
+	for {
+		var sample any
+		err := decoder.Decode(&sample)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %v", len(samples), err)
+		}
+		samples = append(samples, sample)
+	}
+	return Infer(samples...)
+}
+
+// inferValue returns the spec for a single value. The type of the spec is empty if the value is
+// null.
+func inferValue(value any, path path) (*Spec, error) {
+	switch value := value.(type) {
+	case nil:
+		return &Spec{Nullable: true}, nil
+	case bool:
+		return &Spec{Type: Boolean}, nil
+	case string:
+		if _, err := time.Parse(time.RFC3339, value); err == nil {
+			return &Spec{Type: Datetime}, nil
+		}
+		return &Spec{Type: String}, nil
+	case json.Number:
+		if strings.ContainsAny(string(value), ".eE") {
+			return &Spec{Type: Number}, nil
+		}
+		return &Spec{Type: Integer}, nil
+	case float64:
+		if value == math.Trunc(value) && !math.IsInf(value, 0) {
+			return &Spec{Type: Integer}, nil
+		}
+		return &Spec{Type: Number}, nil
+	case int, int64:
+		return &Spec{Type: Integer}, nil
+	case map[string]any:
+		additionalFields := false
+		spec := &Spec{Type: Object, Fields: make(map[string]Field, len(value)), AdditionalFields: &additionalFields}
+		for _, name := range sortedKeys(value) {
+			fieldSpec, err := inferValue(value[name], path.append(name))
+			if err != nil {
+				return nil, err
+			}
+			spec.Fields[name] = Field{Spec: *fieldSpec, Required: true}
+		}
+		return spec, nil
+	case []any:
+		spec := &Spec{Type: Array}
+		for i, element := range value {
+			elementSpec, err := inferValue(element, path.append(i))
+			if err != nil {
+				return nil, err
+			}
+			if spec.Elements == nil {
+				spec.Elements = elementSpec
+				continue
+			}
+			spec.Elements, err = mergeInferred(spec.Elements, elementSpec, path.append(i))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return spec, nil
+	}
+	return nil, fmt.Errorf("%sunsupported value of type %T", path.prefix(), value)
+}
+
+// mergeInferred returns a spec that accepts the values accepted by [a] and [b]. It may modify
+// both.
+func mergeInferred(a, b *Spec, path path) (*Spec, error) {
+	nullable := a.Nullable || b.Nullable
+	switch {
+	case a.Type == "":
+		b.Nullable = nullable
+		return b, nil
+	case b.Type == "":
+		a.Nullable = nullable
+		return a, nil
+	}
+	a.Nullable = nullable
+	switch types := [2]Type{a.Type, b.Type}; {
+	case types == [2]Type{Integer, Number} || types == [2]Type{Number, Integer}:
+		a.Type = Number
+		return a, nil
+	case types == [2]Type{Datetime, String} || types == [2]Type{String, Datetime}:
+		a.Type = String
+		return a, nil
+	case a.Type != b.Type:
+		return nil, fmt.Errorf("%sconflicting types %s and %s", path.prefix(), a.Type, b.Type)
+	}
+	switch a.Type {
+	case Object:
+		for name, field := range a.Fields {
+			other, ok := b.Fields[name]
+			if !ok {
+				field.Required = false
+				a.Fields[name] = field
+				continue
+			}
+			merged, err := mergeInferred(&field.Spec, &other.Spec, path.append(name))
+			if err != nil {
+				return nil, err
+			}
+			a.Fields[name] = Field{Spec: *merged, Required: field.Required && other.Required}
+		}
+		for name, field := range b.Fields {
+			if _, ok := a.Fields[name]; !ok {
+				field.Required = false
+				a.Fields[name] = field
+			}
+		}
+	case Array:
+		if a.Elements == nil {
+			a.Elements = b.Elements
+		} else if b.Elements != nil {
+			elements, err := mergeInferred(a.Elements, b.Elements, path.append("elements"))
+			if err != nil {
+				return nil, err
+			}
+			a.Elements = elements
+		}
+	}
+	return a, nil
+}
+
+// finishInferred sets the type of the values that were always null.
+func finishInferred(spec *Spec) {
+	if spec.Type == "" {
+		spec.Type = String
+	}
+	for name, field := range spec.Fields {
+		finishInferred(&field.Spec)
+		spec.Fields[name] = field
+	}
+	if spec.Elements != nil {
+		finishInferred(spec.Elements)
+	}
+}
@@ -0,0 +1,134 @@
+package jsonspec
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInferJSON(t *testing.T) {
+	closed := ptr(false)
+	cases := []struct {
+		name    string
+		samples string
+		want    *Spec
+	}{
+		{
+			name:    "scalars",
+			samples: `true false`,
+			want:    &Spec{Type: Boolean},
+		},
+		{
+			name:    "integers",
+			samples: `1 -2 30`,
+			want:    &Spec{Type: Integer},
+		},
+		{
+			name:    "integers and numbers",
+			samples: `1 2.0`,
+			want:    &Spec{Type: Number},
+		},
+		{
+			name:    "datetimes",
+			samples: `"2024-03-07T11:38:47Z" "2024-03-07T11:38:47.123-03:00"`,
+			want:    &Spec{Type: Datetime},
+		},
+		{
+			name:    "datetimes and strings",
+			samples: `"2024-03-07T11:38:47Z" "yesterday"`,
+			want:    &Spec{Type: String},
+		},
+		{
+			name:    "null",
+			samples: `null 1`,
+			want:    &Spec{Type: Integer, Nullable: true},
+		},
+		{
+			name:    "always null",
+			samples: `null`,
+			want:    &Spec{Type: String, Nullable: true},
+		},
+		{
+			name: "objects",
+			samples: `
+				{"id": 1, "name": "Jane", "email": null}
+				{"id": 2, "email": "john@example.com", "address": {"city": "Lisbon"}}`,
+			want: &Spec{Type: Object, AdditionalFields: closed, Fields: map[string]Field{
+				"id":    {Spec: Spec{Type: Integer}, Required: true},
+				"name":  {Spec: Spec{Type: String}},
+				"email": {Spec: Spec{Type: String, Nullable: true}, Required: true},
+				"address": {Spec: Spec{Type: Object, AdditionalFields: closed, Fields: map[string]Field{
+					"city": {Spec: Spec{Type: String}, Required: true},
+				}}},
+			}},
+		},
+		{
+			name:    "arrays",
+			samples: `[] [{"score": 1}, {"score": 1.5, "tag": "a"}] [null]`,
+			want: &Spec{Type: Array, Elements: &Spec{Type: Object, Nullable: true, AdditionalFields: closed, Fields: map[string]Field{
+				"score": {Spec: Spec{Type: Number}, Required: true},
+				"tag":   {Spec: Spec{Type: String}},
+			}}},
+		},
+		{
+			name:    "empty arrays",
+			samples: `[]`,
+			want:    &Spec{Type: Array},
+		},
+	}
+	for _, c := range cases {
+		got, err := InferJSON([]byte(c.samples))
+		if err != nil {
+			t.Errorf("%s: InferJSON returned error: %v", c.name, err)
+			continue
+		}
+		if diff := cmp.Diff(c.want, got); diff != "" {
+			t.Errorf("%s: InferJSON result mismatch (-want +got):\n%s", c.name, diff)
+		}
+		for _, sample := range splitSamples(t, c.samples) {
+			if err := got.ValidateJSON(sample); err != nil {
+				t.Errorf("%s: inferred spec rejects %s: %v", c.name, sample, err)
+			}
+		}
+	}
+}
+
+// splitSamples returns the documents in a stream of samples.
+func splitSamples(t *testing.T, samples string) []json.RawMessage {
+	t.Helper()
+	decoder := json.NewDecoder(strings.NewReader(samples))
+	var result []json.RawMessage
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, raw)
+	}
+	return result
+}
+
+func TestInferError(t *testing.T) {
+	cases := []struct {
+		samples string
+		want    string
+	}{
+		{``, "no samples"},
+		{`1 "a"`, "sample 1: conflicting types integer and string"},
+		{`{"tags": ["a"]} {"tags": [1]}`, "sample 1: tags: elements: conflicting types string and integer"},
+		{`[1, {}]`, "sample 0: element 1: conflicting types integer and object"},
+		{`{"a": 1`, "sample 0: unexpected EOF"},
+	}
+	for _, c := range cases {
+		_, err := InferJSON([]byte(c.samples))
+		if err == nil {
+			t.Errorf("InferJSON(%s) did not return error", c.samples)
+			continue
+		}
+		if err.Error() != c.want {
+			t.Errorf("InferJSON(%s) returned %q, want %q", c.samples, err, c.want)
+		}
+	}
+}
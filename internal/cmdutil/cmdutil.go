@@ -0,0 +1,24 @@
+// Package cmdutil contains helpers shared by the commands of the module.
+package cmdutil
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// TypeName returns the name of the type for the spec in the file at [path], for example
+// IntercomArgs for "specs/intercom_args.json".
+func TypeName(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	words := strings.FieldsFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
@@ -0,0 +1,18 @@
+package cmdutil
+
+import "testing"
+
+func TestTypeName(t *testing.T) {
+	cases := []struct {
+		path, want string
+	}{
+		{"intercom_args.json", "IntercomArgs"},
+		{"specs/zendesk-args.json", "ZendeskArgs"},
+		{"v2.json", "V2"},
+	}
+	for _, c := range cases {
+		if got := TypeName(c.path); got != c.want {
+			t.Errorf("TypeName(%q) returned %q, want %q", c.path, got, c.want)
+		}
+	}
+}
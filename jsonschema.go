@@ -0,0 +1,350 @@
+package jsonspec
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// jsonSchemaDialect identifies the version of JSON Schema used by [Spec.JSONSchema].
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema converts the spec to a JSON Schema (draft 2020-12) document. The result can be
+// marshaled with encoding/json.
+//
+// Fields are mapped to "properties" and "required", Elements to "items", Values to
+// "additionalProperties" and Definitions to "$defs". Datetimes become strings with the "date-time"
+// format. Tags, which have no equivalent in JSON Schema, are stored in the "x-tags" annotation.
+// Patterns are translated from the syntax of the regexp package to ECMA-262, which JSON Schema
+// uses, so flags like (?i) and classes like \d keep their meaning.
+func (s *Spec) JSONSchema() map[string]any {
+	e := &schemaExporter{refPrefix: "#/$defs/"}
+	schema := e.schema(s)
+	schema["$schema"] = jsonSchemaDialect
+	if len(s.Definitions) > 0 {
+		defs := make(map[string]any, len(s.Definitions))
+		for name, definition := range s.Definitions {
+			defs[name] = e.schema(definition)
+		}
+		schema["$defs"] = defs
+	}
+	return schema
+}
+
+// A schemaExporter converts specs to JSON Schema.
+type schemaExporter struct {
+	// refPrefix is prepended to the names of definitions to get the value of "$ref".
+	refPrefix string
+
+	// secrets is true if fields tagged "secret" are marked as write-only passwords.
+	secrets bool
+}
+
+// schema returns the JSON Schema for [s], without its definitions.
+func (e *schemaExporter) schema(s *Spec) map[string]any {
+	schema := make(map[string]any)
+	if s.Description != "" {
+		schema["description"] = s.Description
+	}
+	if s.Ref != "" {
+		ref := map[string]any{"$ref": e.refPrefix + s.Ref}
+		if s.AdditionalFields != nil && !*s.AdditionalFields {
+			// additionalProperties would ignore the properties of the referenced schema
+			ref["unevaluatedProperties"] = false
+		}
+		if s.Nullable {
+			schema["anyOf"] = []any{ref, map[string]any{"type": "null"}}
+		} else {
+			for key, value := range ref {
+				schema[key] = value
+			}
+		}
+		return schema
+	}
+
+	typ := string(s.Type)
+	if s.Type == Datetime {
+		typ = string(String)
+		schema["format"] = "date-time"
+	}
+	if s.Nullable {
+		schema["type"] = []any{typ, "null"}
+	} else {
+		schema["type"] = typ
+	}
+
+	if len(s.Enum) > 0 {
+		enum := make([]any, len(s.Enum), len(s.Enum)+1)
+		for i, value := range s.Enum {
+			enum[i] = schemaValue(value)
+		}
+		if s.Nullable {
+			enum = append(enum, nil)
+		}
+		schema["enum"] = enum
+	}
+
+	// numbers
+	setIfNotNil(schema, "minimum", s.Minimum)
+	setIfNotNil(schema, "maximum", s.Maximum)
+	setIfNotNil(schema, "exclusiveMinimum", s.ExclusiveMinimum)
+	setIfNotNil(schema, "exclusiveMaximum", s.ExclusiveMaximum)
+	setIfNotNil(schema, "multipleOf", s.MultipleOf)
+
+	// strings
+	setIfNotNil(schema, "minLength", s.MinLength)
+	setIfNotNil(schema, "maxLength", s.MaxLength)
+	if s.Pattern != "" {
+		schema["pattern"] = ecmaPattern(s.Pattern)
+	}
+
+	// objects
+	if len(s.Fields) > 0 {
+		properties := make(map[string]any, len(s.Fields))
+		var required []string
+		for _, name := range sortedKeys(s.Fields) {
+			field := s.Fields[name]
+			properties[name] = e.property(&field)
+			if field.Required {
+				required = append(required, name)
+			}
+		}
+		schema["properties"] = properties
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+	}
+	if s.Values != nil {
+		schema["additionalProperties"] = e.schema(s.Values)
+	} else if s.AdditionalFields != nil && !*s.AdditionalFields {
+		schema["additionalProperties"] = false
+	}
+
+	// arrays
+	if s.Elements != nil {
+		schema["items"] = e.schema(s.Elements)
+	}
+
+	return schema
+}
+
+// property returns the JSON Schema for a field of an object.
+func (e *schemaExporter) property(field *Field) map[string]any {
+	schema := e.schema(&field.Spec)
+	if field.Default != nil {
+		schema["default"] = schemaValue(field.Default)
+	}
+	if len(field.Tags) > 0 {
+		schema["x-tags"] = field.Tags
+	}
+	if e.secrets && isSecret(field) {
+		schema["writeOnly"] = true
+		if field.Type == String {
+			schema["format"] = "password"
+		}
+	}
+	return schema
+}
+
+// schemaValue converts a value from a spec, like a default or enum value, to its JSON form.
+func schemaValue(value any) any {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return value
+}
+
+// setIfNotNil sets schema[key] to *value if value isn't nil.
+func setIfNotNil[T any](schema map[string]any, key string, value *T) {
+	if value != nil {
+		schema[key] = *value
+	}
+}
+
+// ecmaPattern translates [pattern] from the syntax of the regexp package to the ECMA-262 syntax
+// required by JSON Schema, which has no flags like (?i) and no \A or \z. Classes like \d and \s
+// are written out, because they match more characters in ECMA-262. Invalid patterns are returned
+// as they are.
+func ecmaPattern(pattern string) string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return pattern
+	}
+	var b strings.Builder
+	writeECMA(&b, re)
+	return b.String()
+}
+
+// writeECMA writes [re] in ECMA-262 syntax.
+func writeECMA(b *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		b.WriteString("(?!)")
+	case syntax.OpEmptyMatch:
+		b.WriteString("(?:)")
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 && unicode.SimpleFold(r) != r {
+				writeECMAClass(b, caseVariants(r))
+			} else {
+				b.WriteString(escapeECMA(r, false))
+			}
+		}
+	case syntax.OpCharClass:
+		writeECMAClass(b, re.Rune)
+	case syntax.OpAnyCharNotNL:
+		// "." in ECMA-262 doesn't match \r, \u2028 and \u2029 either
+		b.WriteString(`[^\n]`)
+	case syntax.OpAnyChar:
+		b.WriteString(`[\s\S]`)
+	case syntax.OpBeginLine:
+		b.WriteString(`(?<![^\n])`)
+	case syntax.OpEndLine:
+		b.WriteString(`(?![^\n])`)
+	case syntax.OpBeginText:
+		b.WriteString("^")
+	case syntax.OpEndText:
+		b.WriteString("$")
+	case syntax.OpWordBoundary:
+		b.WriteString(`\b`)
+	case syntax.OpNoWordBoundary:
+		b.WriteString(`\B`)
+	case syntax.OpCapture:
+		b.WriteString("(")
+		if re.Name != "" {
+			b.WriteString("?<" + re.Name + ">")
+		}
+		writeECMA(b, re.Sub[0])
+		b.WriteString(")")
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		writeECMAAtom(b, re.Sub[0])
+		switch {
+		case re.Op == syntax.OpStar:
+			b.WriteString("*")
+		case re.Op == syntax.OpPlus:
+			b.WriteString("+")
+		case re.Op == syntax.OpQuest:
+			b.WriteString("?")
+		case re.Max == -1:
+			fmt.Fprintf(b, "{%d,}", re.Min)
+		case re.Min == re.Max:
+			fmt.Fprintf(b, "{%d}", re.Min)
+		default:
+			fmt.Fprintf(b, "{%d,%d}", re.Min, re.Max)
+		}
+		if re.Flags&syntax.NonGreedy != 0 {
+			b.WriteString("?")
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpAlternate {
+				writeECMAGroup(b, sub)
+			} else {
+				writeECMA(b, sub)
+			}
+		}
+	case syntax.OpAlternate:
+		for i, sub := range re.Sub {
+			if i > 0 {
+				b.WriteString("|")
+			}
+			writeECMA(b, sub)
+		}
+	}
+}
+
+// writeECMAAtom writes [re] so that a repetition applies to all of it.
+func writeECMAAtom(b *strings.Builder, re *syntax.Regexp) {
+	switch {
+	case re.Op == syntax.OpLiteral && len(re.Rune) == 1, re.Op == syntax.OpCharClass,
+		re.Op == syntax.OpAnyChar, re.Op == syntax.OpAnyCharNotNL, re.Op == syntax.OpCapture:
+		writeECMA(b, re)
+	default:
+		writeECMAGroup(b, re)
+	}
+}
+
+func writeECMAGroup(b *strings.Builder, re *syntax.Regexp) {
+	b.WriteString("(?:")
+	writeECMA(b, re)
+	b.WriteString(")")
+}
+
+// writeECMAClass writes the character class with the sorted [ranges], a list of pairs of the first
+// and last rune of each range. Classes that contain most characters are written negated.
+func writeECMAClass(b *strings.Builder, ranges []rune) {
+	if len(ranges) == 2 && ranges[0] == 0 && ranges[1] == unicode.MaxRune {
+		b.WriteString(`[\s\S]`)
+		return
+	}
+	b.WriteString("[")
+	if len(ranges) > 0 && ranges[0] == 0 && ranges[len(ranges)-1] == unicode.MaxRune {
+		// the complement of the class, from the gaps between the ranges
+		complement := make([]rune, 0, len(ranges))
+		for i := 1; i+1 < len(ranges); i += 2 {
+			complement = append(complement, ranges[i]+1, ranges[i+1]-1)
+		}
+		b.WriteString("^")
+		ranges = complement
+	}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		b.WriteString(escapeECMA(lo, true))
+		switch {
+		case hi == lo+1:
+			b.WriteString(escapeECMA(hi, true))
+		case hi > lo:
+			b.WriteString("-" + escapeECMA(hi, true))
+		}
+	}
+	b.WriteString("]")
+}
+
+// caseVariants returns the class of the runes equal to [r] under case folding.
+func caseVariants(r rune) []rune {
+	variants := []rune{r}
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		variants = append(variants, f)
+	}
+	slices.Sort(variants)
+	ranges := make([]rune, 0, 2*len(variants))
+	for _, v := range variants {
+		ranges = append(ranges, v, v)
+	}
+	return ranges
+}
+
+// escapeECMA returns [r] escaped for an ECMA-262 pattern, inside a character class if [inClass] is
+// true.
+func escapeECMA(r rune, inClass bool) string {
+	switch r {
+	case '\t':
+		return `\t`
+	case '\n':
+		return `\n`
+	case '\v':
+		return `\v`
+	case '\f':
+		return `\f`
+	case '\r':
+		return `\r`
+	case '\\', ']', '[', '^':
+		return `\` + string(r)
+	case '-':
+		// with the u flag, "\-" is only valid in a class
+		if inClass {
+			return `\-`
+		}
+	case '.', '+', '*', '?', '(', ')', '|', '{', '}', '$', '/':
+		if !inClass {
+			return `\` + string(r)
+		}
+	}
+	if r < ' ' || r == 0x7f || (r > 0x7f && r <= 0xffff && !unicode.IsPrint(r)) {
+		return fmt.Sprintf(`\u%04x`, r)
+	}
+	return string(r)
+}
@@ -0,0 +1,491 @@
+package jsonspec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// FromJSONSchema converts a JSON Schema document to a [Spec]. It supports the subset of JSON
+// Schema that can be represented by a spec: "type", "properties", "required",
+// "additionalProperties", "items", "enum", "const", "format", "default", "description",
+// "$defs" and "$ref", and the numeric and string constraints. "anyOf" is only supported to make a
+// schema nullable, in the form produced by [Spec.JSONSchema]; the keywords next to it are added to
+// the spec of the other schema in it.
+//
+// Validation keywords that can't be represented cause an error. Annotations and unknown keywords
+// that are ignored are returned as warnings, each of them starting with the location of the
+// keyword as a JSON Pointer.
+func FromJSONSchema(data []byte) (spec *Spec, warnings []string, err error) {
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, nil, err
+	}
+	im := &schemaImporter{}
+	spec, err = im.importRoot(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	return spec, im.warnings, nil
+}
+
+// A schemaImporter converts JSON Schema documents to specs.
+type schemaImporter struct {
+	warnings []string
+}
+
+// ignoredKeywords are annotations that have no equivalent in a spec.
+var ignoredKeywords = map[string]bool{
+	"$id":        true,
+	"$anchor":    true,
+	"$comment":   true,
+	"title":      true,
+	"examples":   true,
+	"deprecated": true,
+	"readOnly":   true,
+	"writeOnly":  true,
+}
+
+func (im *schemaImporter) warn(path path, format string, args ...any) {
+	im.warnings = append(im.warnings, path.pointer()+": "+fmt.Sprintf(format, args...))
+}
+
+func (im *schemaImporter) importRoot(schema map[string]any) (*Spec, error) {
+	// definitions are only supported at the root, where they are easy to refer to
+	var definitions map[string]*Spec
+	for _, key := range []string{"$defs", "definitions"} {
+		defs, ok := schema[key]
+		if !ok {
+			continue
+		}
+		defsMap, ok := defs.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("/%s: expected an object", key)
+		}
+		if definitions == nil {
+			definitions = make(map[string]*Spec, len(defsMap))
+		}
+		for _, name := range sortedKeys(defsMap) {
+			defPath := path{key, name}
+			defSchema, ok := defsMap[name].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: expected an object", defPath.pointer())
+			}
+			definition, err := im.importSchema(defSchema, defPath)
+			if err != nil {
+				return nil, err
+			}
+			definitions[name] = definition
+		}
+	}
+	if s, ok := schema["$schema"].(string); ok && s != jsonSchemaDialect {
+		im.warn(nil, "schema dialect %q may not be fully supported", s)
+	}
+	spec, err := im.importSchema(schema, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(definitions) > 0 {
+		spec.Definitions = definitions
+	}
+	// a spec that refers to a definition has the type of the definition
+	var setRefTypes func(s *Spec) error
+	setRefTypes = func(s *Spec) error {
+		if s.Ref != "" {
+			definition, ok := definitions[s.Ref]
+			if !ok {
+				return fmt.Errorf("undefined reference %q", s.Ref)
+			}
+			s.Type = definition.Type
+		}
+		for name, field := range s.Fields {
+			if err := setRefTypes(&field.Spec); err != nil {
+				return err
+			}
+			s.Fields[name] = field
+		}
+		for _, child := range []*Spec{s.Elements, s.Values} {
+			if child != nil {
+				if err := setRefTypes(child); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	specs := []*Spec{spec}
+	for _, name := range sortedKeys(definitions) {
+		specs = append(specs, definitions[name])
+	}
+	for _, s := range specs {
+		if err := setRefTypes(s); err != nil {
+			return nil, err
+		}
+	}
+	return spec, nil
+}
+
+// importSchema converts the schema at [path] to a spec.
+func (im *schemaImporter) importSchema(schema map[string]any, path path) (*Spec, error) {
+	if anyOf, ok := schema["anyOf"]; ok {
+		// the other keywords are merged into the spec of the schema in "anyOf"
+		return im.importNullable(schema, anyOf, path)
+	}
+	spec := new(Spec)
+	for _, key := range sortedKeys(schema) {
+		value := schema[key]
+		if err := im.importKeyword(spec, schema, key, value, path); err != nil {
+			return nil, err
+		}
+	}
+	if spec.Type == "" && spec.Ref == "" {
+		if len(path) == 0 {
+			return nil, errors.New("missing type")
+		}
+		return nil, fmt.Errorf("%s: missing type", path.pointer())
+	}
+	return spec, nil
+}
+
+// importKeyword stores the value of one keyword of [schema] in [spec].
+func (im *schemaImporter) importKeyword(spec *Spec, schema map[string]any, key string, value any, path path) error {
+	keyPath := path.append(key)
+	invalid := func(expected string) error {
+		return fmt.Errorf("%s: expected %s", keyPath.pointer(), expected)
+	}
+	switch key {
+	case "$schema", "$defs", "definitions":
+		if len(path) > 0 {
+			return fmt.Errorf("%s: only supported at the root of the schema", keyPath.pointer())
+		}
+	case "type":
+		typ, nullable, err := importType(value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", keyPath.pointer(), err)
+		}
+		spec.Type = typ
+		spec.Nullable = spec.Nullable || nullable
+		if spec.Type == String && schema["format"] == "date-time" {
+			spec.Type = Datetime
+		}
+	case "format":
+		if value != "date-time" || !hasType(schema, "string") {
+			im.warn(keyPath, "ignored format %v", value)
+		}
+	case "description":
+		s, ok := value.(string)
+		if !ok {
+			return invalid("a string")
+		}
+		spec.Description = s
+	case "enum", "const":
+		values, ok := value.([]any)
+		if key == "const" {
+			values, ok = []any{value}, true
+		}
+		if !ok {
+			return invalid("an array")
+		}
+		for _, v := range values {
+			if v == nil {
+				spec.Nullable = true
+			} else {
+				spec.Enum = append(spec.Enum, v)
+			}
+		}
+	case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf":
+		n, ok := value.(float64)
+		if !ok {
+			return invalid("a number")
+		}
+		switch key {
+		case "minimum":
+			spec.Minimum = &n
+		case "maximum":
+			spec.Maximum = &n
+		case "exclusiveMinimum":
+			spec.ExclusiveMinimum = &n
+		case "exclusiveMaximum":
+			spec.ExclusiveMaximum = &n
+		case "multipleOf":
+			spec.MultipleOf = &n
+		}
+	case "minLength", "maxLength":
+		n, ok := value.(float64)
+		if !ok || n < 0 || n != math.Trunc(n) {
+			return invalid("a non-negative integer")
+		}
+		length := int(n)
+		if key == "minLength" {
+			spec.MinLength = &length
+		} else {
+			spec.MaxLength = &length
+		}
+	case "pattern":
+		s, ok := value.(string)
+		if !ok {
+			return invalid("a string")
+		}
+		if _, err := compilePattern(s); err != nil {
+			return fmt.Errorf("%s: %v", keyPath.pointer(), err)
+		}
+		spec.Pattern = s
+	case "properties":
+		properties, ok := value.(map[string]any)
+		if !ok {
+			return invalid("an object")
+		}
+		spec.Fields = make(map[string]Field, len(properties))
+		for _, name := range sortedKeys(properties) {
+			field, err := im.importProperty(properties[name], keyPath.append(name))
+			if err != nil {
+				return err
+			}
+			spec.Fields[name] = *field
+		}
+		// "required" is handled together with "properties"
+		if err := setRequired(spec, schema["required"], path.append("required")); err != nil {
+			return err
+		}
+	case "required":
+		if _, ok := schema["properties"]; !ok {
+			return fmt.Errorf("%s: required fields must be listed in properties", keyPath.pointer())
+		}
+	case "additionalProperties", "unevaluatedProperties":
+		switch value := value.(type) {
+		case bool:
+			spec.AdditionalFields = &value
+		case map[string]any:
+			if key == "unevaluatedProperties" {
+				return fmt.Errorf("%s: only supported as a boolean", keyPath.pointer())
+			}
+			values, err := im.importSchema(value, keyPath)
+			if err != nil {
+				return err
+			}
+			spec.Values = values
+		default:
+			return invalid("a boolean or an object")
+		}
+	case "items":
+		items, ok := value.(map[string]any)
+		if !ok {
+			return invalid("an object")
+		}
+		elements, err := im.importSchema(items, keyPath)
+		if err != nil {
+			return err
+		}
+		spec.Elements = elements
+	case "$ref":
+		ref, ok := value.(string)
+		if !ok {
+			return invalid("a string")
+		}
+		name, ok := strings.CutPrefix(ref, "#/$defs/")
+		if !ok {
+			name, ok = strings.CutPrefix(ref, "#/definitions/")
+		}
+		if !ok || strings.Contains(name, "/") {
+			return fmt.Errorf("%s: only references to definitions at the root are supported: %s", keyPath.pointer(), ref)
+		}
+		spec.Ref = name
+	case "default":
+		im.warn(keyPath, "default is only supported for properties")
+	case "x-tags":
+		im.warn(keyPath, "x-tags is only supported for properties")
+	default:
+		if ignoredKeywords[key] || strings.HasPrefix(key, "x-") {
+			im.warn(keyPath, "ignored keyword %s", key)
+			return nil
+		}
+		return fmt.Errorf("%s: unsupported keyword %s", keyPath.pointer(), key)
+	}
+	return nil
+}
+
+// importProperty converts the schema for a property of an object to a field. Defaults and tags
+// are only supported here, because they are part of a [Field], not a [Spec].
+func (im *schemaImporter) importProperty(value any, path path) (*Field, error) {
+	schema, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected an object", path.pointer())
+	}
+	field := new(Field)
+	rest := make(map[string]any, len(schema))
+	for key, v := range schema {
+		switch key {
+		case "default":
+			field.Default = v
+		case "x-tags":
+			tags, ok := v.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: expected an array of strings", path.append(key).pointer())
+			}
+			for _, tag := range tags {
+				s, ok := tag.(string)
+				if !ok {
+					return nil, fmt.Errorf("%s: expected an array of strings", path.append(key).pointer())
+				}
+				field.Tags = append(field.Tags, s)
+			}
+		default:
+			rest[key] = v
+		}
+	}
+	spec, err := im.importSchema(rest, path)
+	if err != nil {
+		return nil, err
+	}
+	field.Spec = *spec
+	return field, nil
+}
+
+// importNullable handles "anyOf" with two schemas, one of which only accepts null. The keywords
+// next to "anyOf" apply to the values accepted by the other schema, so they are added to its spec.
+func (im *schemaImporter) importNullable(schema map[string]any, value any, path path) (*Spec, error) {
+	anyOfPath := path.append("anyOf")
+	errUnsupported := fmt.Errorf("%s: only supported with a schema and {\"type\": \"null\"}", anyOfPath.pointer())
+	options, ok := value.([]any)
+	if !ok || len(options) != 2 {
+		return nil, errUnsupported
+	}
+	for _, key := range []string{"type", "$ref"} {
+		if _, ok := schema[key]; ok {
+			return nil, fmt.Errorf("%s: can't be combined with %s", anyOfPath.pointer(), key)
+		}
+	}
+	var other map[string]any
+	otherPath := anyOfPath
+	for i, option := range options {
+		optionMap, ok := option.(map[string]any)
+		if !ok {
+			return nil, errUnsupported
+		}
+		if len(optionMap) == 1 && optionMap["type"] == "null" {
+			continue
+		}
+		if other != nil {
+			return nil, errUnsupported
+		}
+		other = optionMap
+		otherPath = anyOfPath.append(i)
+	}
+	if other == nil {
+		return nil, errUnsupported
+	}
+	spec, err := im.importSchema(other, otherPath)
+	if err != nil {
+		return nil, err
+	}
+	spec.Nullable = true
+	for _, key := range sortedKeys(schema) {
+		if key == "anyOf" {
+			continue
+		}
+		keyPath := path.append(key)
+		switch {
+		case key == "additionalProperties" && spec.Fields != nil,
+			key == "properties" && (spec.AdditionalFields != nil || spec.Values != nil):
+			// additionalProperties only applies to the properties next to it
+			return nil, fmt.Errorf("%s: properties and additionalProperties can't be split between anyOf and the schema around it, use unevaluatedProperties", keyPath.pointer())
+		}
+		sibling := new(Spec)
+		if err := im.importKeyword(sibling, schema, key, schema[key], path); err != nil {
+			return nil, err
+		}
+		if err := mergeSibling(spec, sibling, keyPath); err != nil {
+			return nil, err
+		}
+	}
+	return spec, nil
+}
+
+// mergeSibling sets the properties of [spec] that are set in [sibling], which holds a keyword next
+// to "anyOf". It returns an error if [spec] already has a different value for one of them.
+func mergeSibling(spec, sibling *Spec, path path) error {
+	target, source := reflect.ValueOf(spec).Elem(), reflect.ValueOf(sibling).Elem()
+	for i := 0; i < source.NumField(); i++ {
+		value := source.Field(i)
+		if value.IsZero() {
+			continue
+		}
+		if current := target.Field(i); !current.IsZero() && !reflect.DeepEqual(current.Interface(), value.Interface()) {
+			return fmt.Errorf("%s: conflicts with the schema in anyOf", path.pointer())
+		}
+		target.Field(i).Set(value)
+	}
+	return nil
+}
+
+// importType converts the value of the "type" keyword.
+func importType(value any) (typ Type, nullable bool, err error) {
+	var names []any
+	switch value := value.(type) {
+	case string:
+		names = []any{value}
+	case []any:
+		names = value
+	default:
+		return "", false, errors.New("expected a string or an array")
+	}
+	for _, name := range names {
+		switch name {
+		case "null":
+			nullable = true
+		case "boolean", "string", "integer", "number", "object", "array":
+			if typ != "" {
+				return "", false, errors.New("multiple types are not supported")
+			}
+			typ = Type(name.(string))
+		default:
+			return "", false, fmt.Errorf("unsupported type %v", name)
+		}
+	}
+	if typ == "" {
+		return "", false, errors.New("a type other than null is required")
+	}
+	return typ, nullable, nil
+}
+
+// hasType returns true if the "type" of [schema] includes [typ].
+func hasType(schema map[string]any, typ string) bool {
+	switch value := schema["type"].(type) {
+	case string:
+		return value == typ
+	case []any:
+		for _, t := range value {
+			if t == typ {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setRequired marks the fields listed in [value], the "required" keyword, as required.
+func setRequired(spec *Spec, value any, path path) error {
+	if value == nil {
+		return nil
+	}
+	names, ok := value.([]any)
+	if !ok {
+		return fmt.Errorf("%s: expected an array", path.pointer())
+	}
+	for _, name := range names {
+		s, ok := name.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected an array of strings", path.pointer())
+		}
+		field, ok := spec.Fields[s]
+		if !ok {
+			return fmt.Errorf("%s: required field %s must be listed in properties", path.pointer(), s)
+		}
+		field.Required = true
+		spec.Fields[s] = field
+	}
+	return nil
+}
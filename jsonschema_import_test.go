@@ -0,0 +1,185 @@
+package jsonspec
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFromJSONSchemaRoundTrip(t *testing.T) {
+	cases := []any{
+		"",
+		[]time.Time{},
+		map[string]*float64{},
+		struct {
+			ID        int    `description:"Unique ID" required:"true" min:"1"`
+			FirstName string `max_length:"100" default:"Jane"`
+			LastName  string `required:"true" pattern:"^[a-z]"`
+			Password  string `required:"true" tags:"secret"`
+			IsAdmin   bool   `default:"false"`
+			Region    Region
+			Score     *float64 `exclusive_min:"0" exclusive_max:"1" multiple_of:"0.5"`
+			Settings  struct {
+				Verbose bool
+			} `additional_fields:"false"`
+		}{},
+		Node{},
+		struct {
+			Home Address
+			Work *Address `description:"Office"`
+		}{},
+	}
+	for _, o := range cases {
+		want, err := For(o)
+		if err != nil {
+			t.Fatalf("For(%v) returned error: %v", o, err)
+		}
+		data, err := json.Marshal(want.JSONSchema())
+		if err != nil {
+			t.Fatalf("json.Marshal returned error: %v", err)
+		}
+		got, warnings, err := FromJSONSchema(data)
+		if err != nil {
+			t.Errorf("FromJSONSchema(%s) returned error: %v", data, err)
+			continue
+		}
+		if len(warnings) > 0 {
+			t.Errorf("FromJSONSchema(%s) returned warnings: %v", data, warnings)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("FromJSONSchema(%s) mismatch (-want +got):\n%s", data, diff)
+		}
+	}
+}
+
+func TestFromJSONSchema(t *testing.T) {
+	data := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "Vendor connector",
+		"type": "object",
+		"properties": {
+			"api_key": {"type": "string", "minLength": 32, "x-tags": ["secret"], "examples": ["abc"]},
+			"region": {"enum": ["us", "eu", null], "type": ["string", "null"], "default": "us"},
+			"mode": {"const": "full", "type": "string"},
+			"email": {"type": "string", "format": "email"},
+			"accounts": {
+				"type": "array",
+				"items": {"$ref": "#/definitions/Account"}
+			}
+		},
+		"required": ["api_key"],
+		"definitions": {
+			"Account": {
+				"type": "object",
+				"properties": {"id": {"type": "integer"}},
+				"additionalProperties": false
+			}
+		}
+	}`
+	want := &Spec{
+		Type: Object,
+		Fields: map[string]Field{
+			"api_key": {Spec: Spec{Type: String, MinLength: ptr(32)}, Required: true, Tags: []string{"secret"}},
+			"region":  {Spec: Spec{Type: String, Nullable: true, Enum: []any{"us", "eu"}}, Default: "us"},
+			"mode":    {Spec: Spec{Type: String, Enum: []any{"full"}}},
+			"email":   {Spec: Spec{Type: String}},
+			"accounts": {Spec: Spec{
+				Type:     Array,
+				Elements: &Spec{Type: Object, Ref: "Account"},
+			}},
+		},
+		Definitions: map[string]*Spec{
+			"Account": {
+				Type:             Object,
+				Fields:           map[string]Field{"id": {Spec: Spec{Type: Integer}}},
+				AdditionalFields: new(bool),
+			},
+		},
+	}
+	wantWarnings := []string{
+		"/properties/api_key/examples: ignored keyword examples",
+		"/properties/email/format: ignored format email",
+		"/title: ignored keyword title",
+	}
+	got, warnings, err := FromJSONSchema([]byte(data))
+	if err != nil {
+		t.Fatalf("FromJSONSchema returned error: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FromJSONSchema result mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantWarnings, warnings); diff != "" {
+		t.Errorf("FromJSONSchema warnings mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFromJSONSchemaAnyOfSiblings(t *testing.T) {
+	// keywords next to anyOf apply to the values accepted by the schema in anyOf
+	schema := `{
+		"anyOf": [{"type": "object", "properties": {"a": {"type": "string"}}}, {"type": "null"}],
+		"unevaluatedProperties": false,
+		"description": "Settings",
+		"title": "settings"
+	}`
+	want := &Spec{
+		Type:             Object,
+		Description:      "Settings",
+		Nullable:         true,
+		AdditionalFields: ptr(false),
+		Fields:           map[string]Field{"a": {Spec: Spec{Type: String}}},
+	}
+	got, warnings, err := FromJSONSchema([]byte(schema))
+	if err != nil {
+		t.Fatalf("FromJSONSchema returned error: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FromJSONSchema result mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"/title: ignored keyword title"}, warnings); diff != "" {
+		t.Errorf("FromJSONSchema warnings mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFromJSONSchemaError(t *testing.T) {
+	cases := []struct {
+		schema, want string
+	}{
+		{`[]`, "json: cannot unmarshal array into Go value of type map[string]interface {}"},
+		{`{"description": "no type"}`, "missing type"},
+		{`{"type": "object", "properties": {"a": {}}}`, "/properties/a: missing type"},
+		{`{"type": ["string", "integer"]}`, "/type: multiple types are not supported"},
+		{`{"type": "date"}`, "/type: unsupported type date"},
+		{`{"type": "array", "minItems": 1}`, "/minItems: unsupported keyword minItems"},
+		{`{"oneOf": [{"type": "string"}, {"type": "integer"}]}`, "/oneOf: unsupported keyword oneOf"},
+		{`{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `/anyOf: only supported with a schema and {"type": "null"}`},
+		{
+			`{"anyOf": [{"type": "object", "properties": {"a": {"type": "string"}}}, {"type": "null"}], "additionalProperties": false}`,
+			"/additionalProperties: properties and additionalProperties can't be split between anyOf and the schema around it, use unevaluatedProperties",
+		},
+		{
+			`{"anyOf": [{"type": "string", "maxLength": 8}, {"type": "null"}], "maxLength": 10}`,
+			"/maxLength: conflicts with the schema in anyOf",
+		},
+		{`{"anyOf": [{"type": "string"}, {"type": "null"}], "minItems": 1}`, "/minItems: unsupported keyword minItems"},
+		{`{"type": "object", "required": ["id"]}`, "/required: required fields must be listed in properties"},
+		{`{"$ref": "https://example.com/schema.json"}`, "/$ref: only references to definitions at the root are supported: https://example.com/schema.json"},
+		{`{"$ref": "#/$defs/Missing"}`, `undefined reference "Missing"`},
+		{`{"type": "string", "pattern": "("}`, "/pattern: invalid pattern \"(\": error parsing regexp: missing closing ): `(`"},
+		{
+			`{"type": "object", "properties": {"a": {"type": "object", "$defs": {}}}}`,
+			"/properties/a/$defs: only supported at the root of the schema",
+		},
+	}
+	for _, c := range cases {
+		_, _, err := FromJSONSchema([]byte(c.schema))
+		if err == nil {
+			t.Errorf("FromJSONSchema(%s) did not return error", c.schema)
+			continue
+		}
+		if got := err.Error(); got != c.want {
+			t.Errorf("FromJSONSchema(%s) returned %q, want %q", c.schema, got, c.want)
+		}
+	}
+}
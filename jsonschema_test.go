@@ -0,0 +1,130 @@
+package jsonspec
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testJSONSchema checks that the JSON Schema for [o] is equivalent to the JSON document [want].
+func testJSONSchema(t *testing.T, o any, want string) {
+	t.Helper()
+
+	spec, err := For(o)
+	if err != nil {
+		t.Fatalf("For(%v) returned error: %v", o, err)
+	}
+	data, err := json.Marshal(spec.JSONSchema())
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+	var got, wantValue any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("invalid JSON in test case: %v", err)
+	}
+	if diff := cmp.Diff(wantValue, got); diff != "" {
+		t.Errorf("JSONSchema() for %T mismatch (-want +got):\n%s", o, diff)
+	}
+}
+
+func TestJSONSchemaPattern(t *testing.T) {
+	tests := []struct {
+		pattern, want string
+	}{
+		{`^[a-z]+$`, `^[a-z]+$`},
+		{`(?i)^ab$`, `^[Aa][Bb]$`},
+		{`\A\d+\z`, `^[0-9]+$`},
+		{`^\S`, `^[^\t\n\f\r ]`},
+		{`\w+@[\w.-]+`, `[0-9A-Z_a-z]+@[\-.0-9A-Z_a-z]+`},
+		{`(?P<year>\d{4})-(\d{2})`, `(?<year>[0-9]{4})-([0-9]{2})`},
+		{`(?m)^a$`, `(?<![^\n])a(?![^\n])`},
+		{`(?s)a.b`, `a[\s\S]b`},
+		{`a.b`, `a[^\n]b`},
+		{`(ab|cd)+?x{2,}`, `(ab|cd)+?x{2,}`},
+		{`v(?:1|20)`, `v(?:1|20)`},
+		{`\.\$\/`, `\.\$\/`},
+		{`[`, `[`},
+	}
+	for _, test := range tests {
+		spec := &Spec{Type: String, Pattern: test.pattern}
+		if got := spec.JSONSchema()["pattern"]; got != test.want {
+			t.Errorf("JSONSchema with pattern %q returned %q, want %q", test.pattern, got, test.want)
+		}
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	testJSONSchema(t, "", `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "string"
+	}`)
+	testJSONSchema(t, []time.Time{}, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "array",
+		"items": {"type": "string", "format": "date-time"}
+	}`)
+	testJSONSchema(t, map[string]*int{}, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"additionalProperties": {"type": ["integer", "null"]}
+	}`)
+	testJSONSchema(t, struct {
+		ID        int    `description:"Unique ID" required:"true" min:"1"`
+		FirstName string `max_length:"100"`
+		LastName  string `required:"true" pattern:"^\\S"`
+		Password  string `required:"true" tags:"secret"`
+		IsAdmin   bool   `default:"false"`
+		Region    Region
+		Score     float64 `exclusive_min:"0" exclusive_max:"1" multiple_of:"0.5"`
+		Settings  struct {
+			Verbose bool
+		} `additional_fields:"false"`
+	}{}, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer", "description": "Unique ID", "minimum": 1},
+			"first_name": {"type": "string", "maxLength": 100},
+			"last_name": {"type": "string", "pattern": "^[^\\t\\n\\f\\r ]"},
+			"password": {"type": "string", "x-tags": ["secret"]},
+			"is_admin": {"type": "boolean", "default": false},
+			"region": {"type": "string", "enum": ["us", "eu", "ap"]},
+			"score": {"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1, "multipleOf": 0.5},
+			"settings": {
+				"type": "object",
+				"properties": {"verbose": {"type": "boolean"}},
+				"additionalProperties": false
+			}
+		},
+		"required": ["id", "last_name", "password"]
+	}`)
+	testJSONSchema(t, struct {
+		Deadline time.Time `default:"2024-03-07T11:38:47Z"`
+	}{}, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"deadline": {"type": "string", "format": "date-time", "default": "2024-03-07T11:38:47Z"}
+		}
+	}`)
+	testJSONSchema(t, Node{}, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$ref": "#/$defs/Node",
+		"$defs": {
+			"Node": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"children": {"type": "array", "items": {"$ref": "#/$defs/Node"}},
+					"parent": {"anyOf": [{"$ref": "#/$defs/Node"}, {"type": "null"}]}
+				},
+				"required": ["name"]
+			}
+		}
+	}`)
+}
@@ -3,15 +3,18 @@ package jsonspec
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 	"reflect"
-	"time"
+	"strconv"
 )
 
 // LoadJSON load a JSON value from [source] into [target]. It returns an error in case of invalid
-// JSON or in case the data doesn't match the spec for [target].
+// JSON or in case the data doesn't match the spec for [target]. Integers are loaded without loss of
+// precision, including into big.Int. Numbers in maps of type map[string]any become float64, like
+// with encoding/json.
 func LoadJSON(source []byte, target any) error {
-	var input any
-	err := json.Unmarshal(source, &input)
+	input, err := decodeJSON(source)
 	if err != nil {
 		return err
 	}
@@ -21,11 +24,11 @@ func LoadJSON(source []byte, target any) error {
 // Load load [source] into [target]. It returns an error in case the data doesn't match the spec
 // for [target].
 func Load(source, target any) error {
-	pointerType := reflect.TypeOf(target)
-	if pointerType.Kind() != reflect.Pointer {
-		return errors.New("argument to Load must be a pointer")
+	pointer := reflect.ValueOf(target)
+	if pointer.Kind() != reflect.Pointer || pointer.IsNil() {
+		return errors.New("argument to Load must be a non-nil pointer")
 	}
-	typ := pointerType.Elem()
+	typ := pointer.Type().Elem()
 
 	// create Spec
 	spec, err := specForType(typ)
@@ -40,77 +43,263 @@ func Load(source, target any) error {
 	}
 
 	// load into target
-	load(spec, source, reflect.ValueOf(target).Elem())
+	l := &loader{definitions: spec.Definitions}
+	return l.load(spec, source, pointer.Elem(), nil)
+}
 
-	return nil
+// A loader holds the state of loading one value.
+type loader struct {
+	// definitions are the definitions of the root spec.
+	definitions map[string]*Spec
 }
 
-func load(spec *Spec, input any, target reflect.Value) {
+func (l *loader) load(spec *Spec, input any, target reflect.Value, path path) error {
+	spec, err := resolve(spec, l.definitions)
+	if err != nil {
+		return err
+	}
+	input = indirect(input)
+	if input == nil {
+		// null leaves pointers, maps and slices nil
+		target.SetZero()
+		return nil
+	}
+	if target.Kind() == reflect.Pointer {
+		pointer := reflect.New(target.Type().Elem())
+		if err := l.load(spec, input, pointer.Elem(), path); err != nil {
+			return err
+		}
+		target.Set(pointer)
+		return nil
+	}
 	switch spec.Type {
 	case Boolean:
-		target.SetBool(input.(bool))
+		b, ok := toBool(input)
+		if !ok {
+			return loadError(spec, input, path)
+		}
+		target.SetBool(b)
 	case String:
-		target.SetString(input.(string))
+		str, ok := toString(input)
+		if !ok {
+			return loadError(spec, input, path)
+		}
+		target.SetString(str)
 	case Integer:
-		switch i := input.(type) {
-		case int:
-			target.SetInt(int64(i))
-		case int64:
-			target.SetInt(i)
-		case float64:
-			target.SetInt(int64(i))
+		n, ok := toRat(input)
+		if !ok || !n.IsInt() {
+			return loadError(spec, input, path)
 		}
+		return setInt(target, n.Num(), path)
 	case Number:
-		switch i := input.(type) {
-		case int:
-			target.SetFloat(float64(i))
-		case int64:
-			target.SetFloat(float64(i))
-		case float64:
-			target.SetFloat(i)
+		n, ok := toNumber(input)
+		if !ok {
+			return loadError(spec, input, path)
 		}
+		return setFloat(target, n, path)
 	case Datetime:
-		switch i := input.(type) {
-		case time.Time:
-			target.Set(reflect.ValueOf(i))
-		case string:
-			t, _ := time.Parse(time.RFC3339, i)
-			target.Set(reflect.ValueOf(t))
+		t, ok := toTime(input)
+		if !ok {
+			return loadError(spec, input, path)
 		}
+		target.Set(reflect.ValueOf(t))
 	case Object:
-		var inputMap map[string]any
-		if input != nil {
-			inputMap = input.(map[string]any)
+		inputMap, ok := toObject(input)
+		if !ok {
+			return loadError(spec, input, path)
 		}
 		typ := target.Type()
 		switch typ.Kind() {
 		case reflect.Map:
-			target.Set(reflect.ValueOf(inputMap))
+			if spec.Values == nil {
+				return setAnyMap(target, inputMap, path)
+			}
+			m := reflect.MakeMapWithSize(typ, len(inputMap))
+			for _, key := range sortedKeys(inputMap) {
+				mapKey, err := loadKey(typ.Key(), key, path)
+				if err != nil {
+					return err
+				}
+				elem := reflect.New(typ.Elem()).Elem()
+				if err := l.load(spec.Values, inputMap[key], elem, path.append(key)); err != nil {
+					return err
+				}
+				m.SetMapIndex(mapKey, elem)
+			}
+			target.Set(m)
 		default:
-			for i := 0; i < typ.NumField(); i++ {
-				name := typ.Field(i).Name
-				key := translateName(name)
+			structFields, err := fieldsOf(typ)
+			if err != nil {
+				return err
+			}
+			for _, structField := range structFields {
+				key := structField.key
 				field, ok := spec.Fields[key]
-				if ok {
-					value := inputMap[key]
-					if value == nil {
-						value = field.Default
-					}
-					if value != nil {
-						load(&field.Spec, value, target.FieldByName(name))
+				if !ok {
+					continue
+				}
+				value, present := inputMap[key]
+				if !present {
+					if field.Default == nil {
+						// leave the zero value, or nil for pointers
+						continue
 					}
+					value = field.Default
+				}
+				if err := l.load(&field.Spec, value, fieldByIndex(target, structField.index), path.append(key)); err != nil {
+					return err
 				}
 			}
 		}
 	case Array:
-		var inputSlice []any
-		if input != nil {
-			inputSlice = input.([]any)
+		inputSlice, ok := toArray(input)
+		if !ok {
+			return loadError(spec, input, path)
 		}
 		slice := reflect.MakeSlice(target.Type(), len(inputSlice), len(inputSlice))
 		for i, v := range inputSlice {
-			load(spec.Elements, v, slice.Index(i))
+			if err := l.load(spec.Elements, v, slice.Index(i), path.append(i)); err != nil {
+				return err
+			}
 		}
 		target.Set(slice)
 	}
+	return nil
+}
+
+// setAnyMap stores [input] in [target], a map whose values are interfaces, so they aren't
+// converted, except for json.Number values, which become float64 like with encoding/json.
+func setAnyMap(target reflect.Value, input map[string]any, path path) error {
+	input = plainNumbers(input).(map[string]any)
+	typ := target.Type()
+	if reflect.TypeOf(input).AssignableTo(typ) {
+		target.Set(reflect.ValueOf(input))
+		return nil
+	}
+	m := reflect.MakeMapWithSize(typ, len(input))
+	for _, key := range sortedKeys(input) {
+		mapKey, err := loadKey(typ.Key(), key, path)
+		if err != nil {
+			return err
+		}
+		value := reflect.ValueOf(input[key])
+		if !value.IsValid() {
+			value = reflect.Zero(typ.Elem())
+		} else if !value.Type().AssignableTo(typ.Elem()) {
+			return newValidationError(path.append(key), CodeTypeMismatch, "", input[key],
+				fmt.Sprintf("cannot load %T into %v", input[key], typ.Elem()))
+		}
+		m.SetMapIndex(mapKey, value)
+	}
+	target.Set(m)
+	return nil
+}
+
+// loadKey converts the object key [key] to a map key of type [typ], which is a string or integer
+// type. The keys of maps with integer keys must be integers in decimal, like with encoding/json.
+func loadKey(typ reflect.Type, key string, path path) (reflect.Value, error) {
+	k := reflect.New(typ).Elem()
+	var err error
+	switch {
+	case k.Kind() == reflect.String:
+		k.SetString(key)
+	case k.CanInt():
+		var n int64
+		n, err = strconv.ParseInt(key, 10, typ.Bits())
+		k.SetInt(n)
+	case k.CanUint():
+		var n uint64
+		n, err = strconv.ParseUint(key, 10, typ.Bits())
+		k.SetUint(n)
+	}
+	if err != nil {
+		return reflect.Value{}, newValidationError(path.append(key), CodeTypeMismatch, Integer, key,
+			fmt.Sprintf("cannot load key %q into %v", key, typ))
+	}
+	return k, nil
+}
+
+// plainNumbers returns a copy of [value] with json.Number values converted to float64.
+func plainNumbers(value any) any {
+	switch value := value.(type) {
+	case json.Number:
+		n, err := value.Float64()
+		if err != nil {
+			// out of range, keep the exact value
+			return value
+		}
+		return n
+	case map[string]any:
+		result := make(map[string]any, len(value))
+		for key, v := range value {
+			result[key] = plainNumbers(v)
+		}
+		return result
+	case []any:
+		result := make([]any, len(value))
+		for i, v := range value {
+			result[i] = plainNumbers(v)
+		}
+		return result
+	}
+	return value
+}
+
+// loadError returns the error for an [input] that doesn't match [spec], which Validate normally
+// catches before loading.
+func loadError(spec *Spec, input any, path path) error {
+	return newValidationError(path, CodeTypeMismatch, spec.Type, input, fmt.Sprintf("cannot load %s as %s", kindOf(input), spec.Type))
+}
+
+// fieldByIndex returns the nested field of the struct [v] at [index], allocating embedded
+// structs that are nil pointers.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, n := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(n)
+	}
+	return v
+}
+
+// setInt stores the integer [n] in [target], which may be of any integer kind. It returns an error
+// if [n] doesn't fit in the target type.
+func setInt(target reflect.Value, n *big.Int, path path) error {
+	switch {
+	case target.Type() == bigIntType:
+		target.Set(reflect.ValueOf(n).Elem())
+	case target.CanInt():
+		if !n.IsInt64() || target.OverflowInt(n.Int64()) {
+			return overflowError(target, n.String(), path)
+		}
+		target.SetInt(n.Int64())
+	case target.CanUint():
+		if !n.IsUint64() || target.OverflowUint(n.Uint64()) {
+			return overflowError(target, n.String(), path)
+		}
+		target.SetUint(n.Uint64())
+	default:
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return setFloat(target, f, path)
+	}
+	return nil
+}
+
+// setFloat stores the number [n] in [target], which may be of any float kind. It returns an error
+// if [n] doesn't fit in the target type.
+func setFloat(target reflect.Value, n float64, path path) error {
+	if target.OverflowFloat(n) {
+		return overflowError(target, formatNumber(n), path)
+	}
+	target.SetFloat(n)
+	return nil
+}
+
+func overflowError(target reflect.Value, n string, path path) error {
+	message := fmt.Sprintf("%s overflows %v", n, target.Type())
+	return newValidationError(path, CodeOutOfRange, Integer, json.Number(n), message)
 }
@@ -1,6 +1,11 @@
 package jsonspec
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
 	"testing"
 	"time"
 
@@ -29,6 +34,16 @@ func TestLoadJSON(t *testing.T) {
 	testLoadJSON(t, `123`, 123.0)
 	testLoadJSON(t, `123.0`, 123.0)
 	testLoadJSON(t, `123.456`, 123.456)
+	testLoadJSON(t, `-123`, int8(-123))
+	testLoadJSON(t, `12345`, int16(12345))
+	testLoadJSON(t, `-1234567`, int32(-1234567))
+	testLoadJSON(t, `1234567890123`, int64(1234567890123))
+	testLoadJSON(t, `255`, uint8(255))
+	testLoadJSON(t, `65535`, uint16(65535))
+	testLoadJSON(t, `4294967295`, uint32(4294967295))
+	testLoadJSON(t, `1234567890123`, uint64(1234567890123))
+	testLoadJSON(t, `123`, uint(123))
+	testLoadJSON(t, `1.5`, float32(1.5))
 	testLoadJSON(t, `"2024-03-07T11:38:47Z"`, time.Date(2024, 3, 7, 11, 38, 47, 0, time.UTC))
 	testLoadJSON(t, `"2024-03-07T11:38:47.123456789Z"`, time.Date(2024, 3, 7, 11, 38, 47, 123456789, time.UTC))
 
@@ -62,7 +77,204 @@ func TestLoadJSON(t *testing.T) {
 		}{FirstName: "Jane", Args: map[string]any{"a": "1", "b": 2.0, "c": map[string]any{"set": true}}},
 	)
 
+	testLoadJSON(t, `{"a": 1, "b": 2}`, map[string]int{"a": 1, "b": 2})
+	testLoadJSON(t, `{"a": [1], "b": [2, 3]}`, map[string][]int{"a": {1}, "b": {2, 3}})
+	testLoadJSON(t,
+		`{"jane": {"first_name": "Jane"}, "joe": {"first_name": "Joe"}}`,
+		map[string]struct{ FirstName string }{"jane": {"Jane"}, "joe": {"Joe"}},
+	)
+
+	type Config struct{ Verbose bool }
+	type Pointers struct {
+		Nickname *string
+		Retries  *int `default:"3"`
+		Config   *Config
+	}
+	nickname, retries := "JD", 5
+	defaultRetries := 3
+	testLoadJSON(t, `{}`, Pointers{Retries: &defaultRetries})
+	testLoadJSON(t, `{"nickname": null, "retries": null, "config": null}`, Pointers{})
+	testLoadJSON(t,
+		`{"nickname": "JD", "retries": 5, "config": {"verbose": true}}`,
+		Pointers{Nickname: &nickname, Retries: &retries, Config: &Config{Verbose: true}},
+	)
+	testLoadJSON(t, `[1, null]`, []*int{ptr(1), nil})
+
+	testLoadJSON(t,
+		`{"userId": "jd", "first_name": "Jane", "password": "hunter2"}`,
+		struct {
+			UserID    string `json:"userId"`
+			FirstName string `json:"first_name,omitempty"`
+			Password  string `json:"-"`
+		}{UserID: "jd", FirstName: "Jane"},
+	)
+
+	type Args struct {
+		BaseConnectorArgs
+		*OAuthCredentials
+		Workspace string
+	}
+	testLoadJSON(t,
+		`{"name": "intercom", "client_id": "abc", "workspace": "acme"}`,
+		Args{
+			BaseConnectorArgs: BaseConnectorArgs{Name: "intercom", Timeout: 30},
+			OAuthCredentials:  &OAuthCredentials{ClientID: "abc"},
+			Workspace:         "acme",
+		},
+	)
+	testLoadJSON(t,
+		`{"name": "intercom", "timeout": 10}`,
+		Args{BaseConnectorArgs: BaseConnectorArgs{Name: "intercom", Timeout: 10}},
+	)
+
+	testLoadJSON(t,
+		`{"name": "root", "children": [{"name": "a", "children": [{"name": "a.1"}]}, {"name": "b"}]}`,
+		Node{Name: "root", Children: []Node{{Name: "a", Children: []Node{{Name: "a.1"}}}, {Name: "b"}}},
+	)
+
 	testLoadJSON(t, `["jane", "joe", "julia"]`, []string{"jane", "joe", "julia"})
 	testLoadJSON(t, `[1, 2, 3]`, []int{1, 2, 3})
 	testLoadJSON(t, `[[1], [2, 3]]`, [][]int{{1}, {2, 3}})
+	testLoadJSON(t, `{"1": "a", "-2": "b"}`, map[int]string{1: "a", -2: "b"})
+	testLoadJSON(t, `{"80": true}`, map[uint16]any{80: true})
+}
+
+func TestLoadJSONError(t *testing.T) {
+	cases := []struct {
+		input  string
+		target any
+		want   string
+	}{
+		{`256`, new(uint8), "must be at most 255"},
+		{`-1`, new(uint16), "must be at least 0"},
+		{`-1`, new(uint64), "must be at least 0"},
+		{`1e19`, new(int64), "10000000000000000000 overflows int64"},
+		{`1e20`, new(uint64), "100000000000000000000 overflows uint64"},
+		{`1e39`, new(float32), "1000000000000000000000000000000000000000 overflows float32"},
+		{`9223372036854775808`, new(int64), "9223372036854775808 overflows int64"},
+		{`18446744073709551616`, new(uint64), "18446744073709551616 overflows uint64"},
+		{`9007199254740993.5`, new(int64), "expected an integer"},
+		{`1e1001`, new(big.Int), "number is out of the supported range"},
+		{`1 2`, new(int), "invalid character '2' after top-level value"},
+		{`{"verbose": null}`, new(struct{ Verbose bool }), "verbose: must not be null"},
+		{`{"retries": 40000}`, new(struct{ Retries int16 }), "retries: must be at most 32767"},
+		{`{"values": [1, 1e20]}`, new(struct{ Values []int64 }), "values: element 1: 100000000000000000000 overflows int64"},
+		{`1`, nil, "argument to Load must be a non-nil pointer"},
+		{`1`, (*int)(nil), "argument to Load must be a non-nil pointer"},
+		{`1`, 1, "argument to Load must be a non-nil pointer"},
+		{`{"a": "x"}`, new(map[int]string), `a: cannot load key "a" into int`},
+		{`{"300": "x"}`, new(map[uint8]string), `300: cannot load key "300" into uint8`},
+		{`{"-1": 1}`, new(map[uint]any), `-1: cannot load key "-1" into uint`},
+		{`{"acess_token": "x"}`, new(ZendeskArgs), `unknown field "acess_token", did you mean "access_token"?`},
+	}
+	for _, c := range cases {
+		err := LoadJSON([]byte(c.input), c.target)
+		if err == nil {
+			t.Errorf("LoadJSON(%q) did not return error", c.input)
+			continue
+		}
+		if got := err.Error(); got != c.want {
+			t.Errorf("LoadJSON(%q) returned %q, want %q", c.input, got, c.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	type Args struct {
+		ID      int64
+		Retries uint8
+		Ratio   float32
+		Region  Region
+		Scopes  []string
+		Labels  map[string]int
+		Extra   map[string]any
+		Nodes   []*int
+	}
+	input := map[string]any{
+		"id":      json.Number("1234567890123"),
+		"retries": int32(3),
+		"ratio":   json.Number("0.5"),
+		"region":  "eu",
+		"scopes":  []string{"read", "write"},
+		"labels":  map[string]int64{"tier": 1},
+		"extra":   map[string]string{"debug": "true"},
+		"nodes":   []*int{ptr(1), nil},
+	}
+	want := Args{
+		ID:      1234567890123,
+		Retries: 3,
+		Ratio:   0.5,
+		Region:  "eu",
+		Scopes:  []string{"read", "write"},
+		Labels:  map[string]int{"tier": 1},
+		Extra:   map[string]any{"debug": "true"},
+		Nodes:   []*int{ptr(1), nil},
+	}
+	var got Args
+	if err := Load(input, &got); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Load result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadMismatch(t *testing.T) {
+	// load must not panic on values that Validate would reject
+	cases := []struct {
+		input  any
+		target any
+		want   string
+	}{
+		{"true", new(bool), "cannot load string as boolean"},
+		{1, new(string), "cannot load number as string"},
+		{1.5, new(int), "cannot load number as integer"},
+		{"1", new(float64), "cannot load string as number"},
+		{"yesterday", new(time.Time), "cannot load string as datetime"},
+		{[]any{}, new(struct{ A int }), "cannot load array as object"},
+		{map[string]any{"a": []any{"x"}}, new(struct{ A []int }), "a: element 0: cannot load string as integer"},
+		{map[string]any{"a": 1}, new(map[string]fmt.Stringer), "a: cannot load int into fmt.Stringer"},
+	}
+	for _, c := range cases {
+		spec, err := specForType(reflect.TypeOf(c.target).Elem())
+		if err != nil {
+			t.Fatalf("specForType returned error: %v", err)
+		}
+		err = (&loader{}).load(spec, c.input, reflect.ValueOf(c.target).Elem(), nil)
+		if err == nil {
+			t.Errorf("load(%v) did not return error", c.input)
+			continue
+		}
+		if got := err.Error(); got != c.want {
+			t.Errorf("load(%v) returned %q, want %q", c.input, got, c.want)
+		}
+	}
+}
+
+func TestLoadJSONPrecise(t *testing.T) {
+	testLoadJSON(t, `9007199254740993`, int64(9007199254740993))
+	testLoadJSON(t, `-9223372036854775808`, int64(math.MinInt64))
+	testLoadJSON(t, `18446744073709551615`, uint64(math.MaxUint64))
+	testLoadJSON(t, `1.8446744073709551615e19`, uint64(math.MaxUint64))
+	testLoadJSON(t, `[9007199254740993]`, []int64{9007199254740993})
+	testLoadJSON(t, `{"id": 9007199254740993}`, struct{ ID uint64 }{9007199254740993})
+
+	// untyped values are float64 like with encoding/json
+	testLoadJSON(t, `{"a": 1, "b": [2.5]}`, map[string]any{"a": 1.0, "b": []any{2.5}})
+
+	const large = "123456789012345678901234567890"
+	var n big.Int
+	if err := LoadJSON([]byte(large), &n); err != nil {
+		t.Fatalf("LoadJSON returned error: %v", err)
+	}
+	if got := n.String(); got != large {
+		t.Errorf("LoadJSON loaded %s, want %s", got, large)
+	}
+	var args struct{ ID, Parent *big.Int }
+	if err := LoadJSON([]byte(`{"id": `+large+`, "parent": null}`), &args); err != nil {
+		t.Fatalf("LoadJSON returned error: %v", err)
+	}
+	if args.ID == nil || args.ID.String() != large || args.Parent != nil {
+		t.Errorf("LoadJSON loaded %v, want {%s <nil>}", args, large)
+	}
 }
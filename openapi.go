@@ -0,0 +1,53 @@
+package jsonspec
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// OpenAPIComponents generates an OpenAPI 3.1 Components Object with a schema for the type of each
+// of [values], generated with [For] and converted like [Spec.JSONSchema]. The result has the form
+// {"schemas": {...}} and belongs under "components" in an OpenAPI document.
+//
+// Schemas are named after their Go types, which must be named, with characters other than letters,
+// digits and underscores replaced by underscores. Definitions of the specs become
+// schemas too, so references work across types. Fields tagged "secret" are marked as "writeOnly",
+// and secret strings get the "password" format.
+func OpenAPIComponents(values ...any) (map[string]any, error) {
+	e := &schemaExporter{refPrefix: "#/components/schemas/", secrets: true}
+	schemas := make(map[string]any)
+	add := func(name string, schema map[string]any) error {
+		if existing, ok := schemas[name]; ok && !reflect.DeepEqual(existing, schema) {
+			return fmt.Errorf("conflicting schemas named %s", name)
+		}
+		schemas[name] = schema
+		return nil
+	}
+	for _, value := range values {
+		typ := reflect.TypeOf(value)
+		if typ != nil && typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		if typ == nil || typ.Name() == "" {
+			return nil, fmt.Errorf("cannot name schema for unnamed type %v", typ)
+		}
+		name := nonIdentifierRe.ReplaceAllString(typ.Name(), "_")
+		spec, err := specForType(typ)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range sortedKeys(spec.Definitions) {
+			if err := add(name, e.schema(spec.Definitions[name])); err != nil {
+				return nil, err
+			}
+		}
+		if spec.Ref == name {
+			// the type itself is a definition
+			continue
+		}
+		if err := add(name, e.schema(spec)); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]any{"schemas": schemas}, nil
+}
@@ -0,0 +1,86 @@
+package jsonspec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type IntercomArgs struct {
+	OAuthCredentials
+	Workspace string  `required:"true"`
+	Office    Address `description:"Main office"`
+	Branches  []Address
+}
+
+func TestOpenAPIComponents(t *testing.T) {
+	components, err := OpenAPIComponents(IntercomArgs{}, new(Node), Address{})
+	if err != nil {
+		t.Fatalf("OpenAPIComponents returned error: %v", err)
+	}
+	data, err := json.Marshal(components)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+	want := `{
+		"schemas": {
+			"Address": {
+				"type": "object",
+				"properties": {"street": {"type": "string"}}
+			},
+			"IntercomArgs": {
+				"type": "object",
+				"properties": {
+					"client_id": {"type": "string"},
+					"client_secret": {
+						"type": "string",
+						"x-tags": ["secret"],
+						"writeOnly": true,
+						"format": "password"
+					},
+					"workspace": {"type": "string"},
+					"office": {"$ref": "#/components/schemas/Address", "description": "Main office"},
+					"branches": {"type": "array", "items": {"$ref": "#/components/schemas/Address"}}
+				},
+				"required": ["workspace"]
+			},
+			"Node": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"children": {"type": "array", "items": {"$ref": "#/components/schemas/Node"}},
+					"parent": {"anyOf": [{"$ref": "#/components/schemas/Node"}, {"type": "null"}]}
+				},
+				"required": ["name"]
+			}
+		}
+	}`
+	var got, wantValue any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("invalid JSON in test case: %v", err)
+	}
+	if diff := cmp.Diff(wantValue, got); diff != "" {
+		t.Errorf("OpenAPIComponents result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOpenAPIComponentsError(t *testing.T) {
+	type Address struct {
+		Line1 string
+	}
+	cases := [][]any{
+		{struct{ Name string }{}},
+		{nil},
+		{IntercomArgs{}, Address{}},
+	}
+	for _, values := range cases {
+		_, err := OpenAPIComponents(values...)
+		if err == nil {
+			t.Errorf("OpenAPIComponents(%v) did not return error", values)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package jsonspec
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+)
+
+// RedactedValue replaces the values of secret fields in redacted documents.
+const RedactedValue = "[REDACTED]"
+
+// secretTag is the tag of fields with values that must not be shown, like passwords.
+const secretTag = "secret"
+
+// isSecret returns true if [field] is tagged "secret".
+func isSecret(field *Field) bool {
+	return slices.Contains(field.Tags, secretTag)
+}
+
+// Redact returns [value], a document decoded from JSON, with the values of fields tagged "secret"
+// replaced by [RedactedValue], so the document can be logged or shown. Secret fields in nested
+// objects and array elements are redacted too. Like [Spec.Validate], Redact accepts documents with
+// typed maps and slices, like []map[string]any, which are copied to map[string]any and []any.
+// [value] isn't modified. Null values are kept, and
+// parts of the document that don't match the spec are kept as they are, so invalid documents can be
+// redacted too.
+func (s *Spec) Redact(value any) any {
+	return redact(s, value, s.Definitions)
+}
+
+// RedactJSON is like [Spec.Redact], for a JSON document. It accepts the same documents as
+// [Spec.ValidateJSON], and numbers are kept as they are written.
+func (s *Spec) RedactJSON(data []byte) ([]byte, error) {
+	value, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(s.Redact(value))
+}
+
+func redact(s *Spec, value any, definitions map[string]*Spec) any {
+	s, err := resolve(s, definitions)
+	if err != nil {
+		return value
+	}
+	switch s.Type {
+	case Object:
+		object, ok := toObject(indirect(value))
+		if !ok {
+			return value
+		}
+		result := make(map[string]any, len(object))
+		for key, v := range object {
+			field, ok := s.Fields[key]
+			switch {
+			case ok && isSecret(&field) && indirect(v) != nil:
+				result[key] = RedactedValue
+			case ok:
+				result[key] = redact(&field.Spec, v, definitions)
+			case s.Values != nil:
+				result[key] = redact(s.Values, v, definitions)
+			default:
+				result[key] = v
+			}
+		}
+		return result
+	case Array:
+		array, ok := toArray(indirect(value))
+		if !ok || s.Elements == nil {
+			return value
+		}
+		result := make([]any, len(array))
+		for i, v := range array {
+			result[i] = redact(s.Elements, v, definitions)
+		}
+		return result
+	}
+	return value
+}
+
+// MarshalRedacted returns the JSON encoding of [value] in the format described by the spec
+// generated for its type by [For], so keys follow the same naming convention as in [Load]. The
+// values of fields tagged "secret" are replaced by [RedactedValue].
+func MarshalRedacted(value any) ([]byte, error) {
+	document, err := (&dumper{redact: true}).dumpValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
+
+// Redacted returns a [slog.LogValuer] for [value], so it can be logged like [MarshalRedacted]
+// encodes it. Objects become groups:
+//
+//	logger.Info("loaded connector", "args", jsonspec.Redacted(args))
+//
+// Types can also implement slog.LogValuer themselves by returning Redacted(v).LogValue().
+func Redacted(value any) slog.LogValuer {
+	return redactedValue{value}
+}
+
+type redactedValue struct {
+	value any
+}
+
+// LogValue implements [slog.LogValuer].
+func (r redactedValue) LogValue() slog.Value {
+	document, err := (&dumper{redact: true}).dumpValue(r.value)
+	if err != nil {
+		return slog.StringValue("!ERROR: " + err.Error())
+	}
+	return logValue(document)
+}
+
+// logValue converts a document to a slog value, with groups for objects.
+func logValue(document any) slog.Value {
+	object, ok := document.(map[string]any)
+	if !ok {
+		return slog.AnyValue(document)
+	}
+	attrs := make([]slog.Attr, 0, len(object))
+	for _, key := range sortedKeys(object) {
+		attrs = append(attrs, slog.Attr{Key: key, Value: logValue(object[key])})
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,201 @@
+package jsonspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSpecRedact(t *testing.T) {
+	type Credentials struct {
+		User     string
+		Password string `tags:"secret"`
+	}
+	type Args struct {
+		Name        string
+		APIKey      string  `tags:"secret,pii"`
+		Token       *string `tags:"secret"`
+		Credentials Credentials
+		Backups     []Credentials
+		Extra       map[string]Credentials
+		Tree        *Node
+	}
+	spec, err := For(Args{})
+	if err != nil {
+		t.Fatalf("For returned error: %v", err)
+	}
+	input := `{
+		"name": "intercom",
+		"api_key": "abc",
+		"token": null,
+		"credentials": {"user": "jane", "password": "123"},
+		"backups": [{"user": "john", "password": "456"}, {"user": "joe"}],
+		"extra": {"eu": {"user": "jim", "password": 789}},
+		"tree": {"name": "root", "children": [{"name": "leaf"}]},
+		"unknown": {"password": "kept"}
+	}`
+	var value any
+	if err := json.Unmarshal([]byte(input), &value); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"name":        "intercom",
+		"api_key":     RedactedValue,
+		"token":       nil,
+		"credentials": map[string]any{"user": "jane", "password": RedactedValue},
+		"backups": []any{
+			map[string]any{"user": "john", "password": RedactedValue},
+			map[string]any{"user": "joe"},
+		},
+		"extra":   map[string]any{"eu": map[string]any{"user": "jim", "password": RedactedValue}},
+		"tree":    map[string]any{"name": "root", "children": []any{map[string]any{"name": "leaf"}}},
+		"unknown": map[string]any{"password": "kept"},
+	}
+	got := spec.Redact(value)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Redact result mismatch (-want +got):\n%s", diff)
+	}
+	if credentials := value.(map[string]any)["credentials"].(map[string]any); credentials["password"] != "123" {
+		t.Errorf("Redact modified its input: %v", credentials)
+	}
+}
+
+func TestSpecRedactTyped(t *testing.T) {
+	type Credentials struct {
+		User     string
+		Password string `tags:"secret"`
+	}
+	type Args struct {
+		Backups []Credentials
+		Extra   map[string]Credentials
+		Token   *string `tags:"secret"`
+	}
+	spec, err := For(Args{})
+	if err != nil {
+		t.Fatalf("For returned error: %v", err)
+	}
+	token := "abc"
+	value := map[string]any{
+		"backups": []map[string]any{{"user": "john", "password": "456"}},
+		"extra":   map[string]map[string]string{"eu": {"user": "jim", "password": "789"}},
+		"token":   &token,
+	}
+	want := map[string]any{
+		"backups": []any{map[string]any{"user": "john", "password": RedactedValue}},
+		"extra":   map[string]any{"eu": map[string]any{"user": "jim", "password": RedactedValue}},
+		"token":   RedactedValue,
+	}
+	got := spec.Redact(value)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Redact result mismatch (-want +got):\n%s", diff)
+	}
+
+	var nilToken *string
+	got = spec.Redact(map[string]any{"token": nilToken})
+	if diff := cmp.Diff(map[string]any{"token": nilToken}, got); diff != "" {
+		t.Errorf("Redact result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSpecRedactJSON(t *testing.T) {
+	spec := &Spec{Type: Object, Fields: map[string]Field{
+		"id":  {Spec: Spec{Type: Integer}},
+		"pin": {Spec: Spec{Type: Integer}, Tags: []string{"secret"}},
+	}}
+	got, err := spec.RedactJSON([]byte(`{"id": 12345678901234567890, "pin": 1234}`))
+	if err != nil {
+		t.Fatalf("RedactJSON returned error: %v", err)
+	}
+	want := `{"id":12345678901234567890,"pin":"[REDACTED]"}`
+	if string(got) != want {
+		t.Errorf("RedactJSON returned %s, want %s", got, want)
+	}
+	for _, input := range []string{`{"id":`, `{"pin": 1234} garbage`, `{} {}`} {
+		if _, err := spec.RedactJSON([]byte(input)); err == nil {
+			t.Errorf("RedactJSON(%s) did not return error", input)
+		}
+	}
+}
+
+type LoggedArgs struct {
+	OAuthCredentials
+	Workspace string
+	Ratio     float32
+	Started   time.Time
+	Tokens    []string `tags:"secret"`
+	Scopes    []string
+	Extra     map[string]any
+}
+
+var loggedArgs = LoggedArgs{
+	OAuthCredentials: OAuthCredentials{ClientID: "abc", ClientSecret: "hunter2"},
+	Workspace:        "acme",
+	Ratio:            0.1,
+	Started:          time.Date(2024, 3, 7, 11, 38, 47, 0, time.UTC),
+	Tokens:           []string{"t1"},
+	Extra:            map[string]any{"debug": true},
+}
+
+func TestMarshalRedacted(t *testing.T) {
+	got, err := MarshalRedacted(&loggedArgs)
+	if err != nil {
+		t.Fatalf("MarshalRedacted returned error: %v", err)
+	}
+	want := `{"client_id":"abc","client_secret":"[REDACTED]","extra":{"debug":true},"ratio":0.1,` +
+		`"started":"2024-03-07T11:38:47Z","tokens":"[REDACTED]","workspace":"acme"}`
+	if string(got) != want {
+		t.Errorf("MarshalRedacted returned %s, want %s", got, want)
+	}
+
+	if _, err := MarshalRedacted(func() {}); err == nil {
+		t.Errorf("MarshalRedacted did not return error for a func")
+	}
+}
+
+func TestMarshalRedactedInterface(t *testing.T) {
+	type Inner struct {
+		Token    string `tags:"secret"`
+		UserName string
+	}
+	type Outer struct {
+		Raw map[string]any
+	}
+	value := Outer{Raw: map[string]any{
+		"in":     Inner{Token: "z", UserName: "jane"},
+		"ptr":    &Inner{Token: "y"},
+		"list":   []any{Inner{Token: "x"}, 1.5},
+		"nested": map[string]any{"in": Inner{Token: "w"}},
+		"nil":    (*Inner)(nil),
+	}}
+	got, err := MarshalRedacted(value)
+	if err != nil {
+		t.Fatalf("MarshalRedacted returned error: %v", err)
+	}
+	want := `{"raw":{"in":{"token":"[REDACTED]","user_name":"jane"},"list":[{"token":"[REDACTED]","user_name":""},1.5],` +
+		`"nested":{"in":{"token":"[REDACTED]","user_name":""}},"nil":null,"ptr":{"token":"[REDACTED]","user_name":""}}}`
+	if string(got) != want {
+		t.Errorf("MarshalRedacted returned %s, want %s", got, want)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	var b bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&b, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("loaded", "args", Redacted(loggedArgs))
+	want := `{"level":"INFO","msg":"loaded","args":{"client_id":"abc","client_secret":"[REDACTED]",` +
+		`"extra":{"debug":true},"ratio":0.1,"started":"2024-03-07T11:38:47Z","tokens":"[REDACTED]","workspace":"acme"}}` + "\n"
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("log output mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -2,11 +2,20 @@
 package jsonspec
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 // A Type is one of the valid types for fields.
@@ -31,112 +40,733 @@ type Spec struct {
 	// Description contains an optional description of the spec.
 	Description string `json:"description,omitempty"`
 
+	// Nullable is true if null is a valid value.
+	Nullable bool `json:"nullable,omitempty"`
+
 	// Fields defines the fields of an object. Only relevant if Type is [Object].
 	Fields map[string]Field `json:"fields,omitempty"`
 
+	// Values defines the values of all fields of an object that aren't listed in Fields, for
+	// dictionary-like objects with arbitrary keys. Only relevant if Type is [Object].
+	Values *Spec `json:"values,omitempty"`
+
+	// AdditionalFields controls whether an object may contain fields not listed in Fields. Unknown
+	// fields are allowed unless it is set to false. It has no effect if Values is set. Only relevant
+	// if Type is [Object].
+	AdditionalFields *bool `json:"additional_fields,omitempty"`
+
+	// Minimum is the smallest valid value. Only relevant if Type is [Integer] or [Number].
+	Minimum *float64 `json:"minimum,omitempty"`
+
+	// Maximum is the largest valid value. Only relevant if Type is [Integer] or [Number].
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	// ExclusiveMinimum is a value that valid values must be greater than. Only relevant if Type is
+	// [Integer] or [Number].
+	ExclusiveMinimum *float64 `json:"exclusive_minimum,omitempty"`
+
+	// ExclusiveMaximum is a value that valid values must be less than. Only relevant if Type is
+	// [Integer] or [Number].
+	ExclusiveMaximum *float64 `json:"exclusive_maximum,omitempty"`
+
+	// MultipleOf, if set, requires valid values to be a multiple of it. It must be greater than
+	// zero. Only relevant if Type is [Integer] or [Number].
+	MultipleOf *float64 `json:"multiple_of,omitempty"`
+
+	// MinLength is the minimum number of characters (runes) of a string. Only relevant if Type is
+	// [String].
+	MinLength *int `json:"min_length,omitempty"`
+
+	// MaxLength is the maximum number of characters (runes) of a string. Only relevant if Type is
+	// [String].
+	MaxLength *int `json:"max_length,omitempty"`
+
+	// Pattern is a regular expression in the syntax of the [regexp] package that strings have to
+	// match. The pattern isn't anchored, so use ^ and $ to match the whole string. Only relevant if
+	// Type is [String].
+	Pattern string `json:"pattern,omitempty"`
+
+	// Enum, if set, lists the only values that are valid.
+	Enum []any `json:"enum,omitempty"`
+
 	// Elements defines the elements of an array. Only relevant if Type is [Array].
 	Elements *Spec `json:"elements,omitempty"`
+
+	// Ref, if set, is the name of a definition in the Definitions of the root spec. The value is
+	// validated against that definition instead; Nullable, Description and AdditionalFields of
+	// this spec take precedence over those of the definition.
+	Ref string `json:"ref,omitempty"`
+
+	// Definitions contains named specs that can be referenced with Ref, for recursive types and
+	// types used in several places. Only relevant in the root spec, so nested specs that refer to
+	// them can't be validated on their own; use [Spec.FieldSpec] to get them with the definitions.
+	Definitions map[string]*Spec `json:"definitions,omitempty"`
 }
 
 // A Field defines one field in a JSON object.
 type Field struct {
 	Spec
 
-	// Required is true if the field has to be set for the object to be valid.
+	// Required is true if the field has to be present for the object to be valid. A field that is
+	// present but set to null is only valid if the spec is nullable, regardless of Required.
 	Required bool `json:"required,omitempty"`
 
-	// Default sets a default value for the field.
+	// Default sets a default value for the field, used by [Load] if the field is absent (but not if
+	// it is null).
 	Default any `json:"default,omitempty"`
 
 	// Tags is a list of custom tags.
 	Tags []string `json:"tags,omitempty"`
 }
 
-// ValidateJSON returns an error if [value] doesn't match the spec.
+// FieldSpec returns the spec of the field [name] of the object [s] with the definitions of [s], so
+// it can be used on its own, for example to validate a single field. It returns nil if there is
+// no such field.
+func (s *Spec) FieldSpec(name string) *Spec {
+	resolved, err := resolve(s, s.Definitions)
+	if err != nil {
+		return nil
+	}
+	field, ok := resolved.Fields[name]
+	if !ok {
+		return nil
+	}
+	spec := field.Spec
+	spec.Definitions = s.Definitions
+	return &spec
+}
+
+// ValidateJSON returns an error if [value] doesn't match the spec. Numbers are checked against the
+// spec exactly as they are written, so integers beyond the precision of float64 are handled too.
 func (s *Spec) ValidateJSON(data []byte) error {
-	var input any
-	err := json.Unmarshal(data, &input)
+	input, err := decodeJSON(data)
 	if err != nil {
 		return err
 	}
 	return s.Validate(input)
 }
 
-// Validate returns an error if [value] doesn't match the spec.
+// decodeJSON decodes a JSON document like json.Unmarshal, except that numbers are decoded as
+// json.Number to keep their precision. Invalid documents, including those with data after the
+// value, are reported with the *json.SyntaxError of json.Unmarshal.
+func decodeJSON(data []byte) (any, error) {
+	var value any
+	if !json.Valid(data) {
+		return nil, json.Unmarshal(data, &value)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Validate returns an error if [value] doesn't match the spec. It stops at the first invalid value
+// it finds; use [Spec.ValidateAll] to get all errors.
 func (s *Spec) Validate(value any) error {
+	v := &validator{definitions: s.Definitions}
+	v.validate(s, value, nil)
+	if len(v.errs) > 0 {
+		return v.errs[0]
+	}
+	return nil
+}
+
+// ValidateAll is like [Spec.Validate], but it checks the whole value instead of stopping at the
+// first error. All errors are returned as one error created with [errors.Join], ordered by their
+// location in the value (object fields sorted by name, array elements by index).
+func (s *Spec) ValidateAll(value any) error {
+	v := &validator{all: true, definitions: s.Definitions}
+	v.validate(s, value, nil)
+	return errors.Join(v.errs...)
+}
+
+// A validator holds the state of validating one value against a spec.
+type validator struct {
+	// all is true if validation continues after the first error.
+	all  bool
+	errs []error
+
+	// definitions are the definitions of the root spec.
+	definitions map[string]*Spec
+}
+
+// fail records an error for [value] at [path].
+func (v *validator) fail(path path, code ErrorCode, expected Type, value any, message string) {
+	v.errs = append(v.errs, newValidationError(path, code, expected, value, message))
+}
+
+// done returns true if validation should stop.
+func (v *validator) done() bool {
+	return !v.all && len(v.errs) > 0
+}
+
+func (v *validator) validate(s *Spec, value any, path path) {
+	s, err := resolve(s, v.definitions)
+	if err != nil {
+		v.fail(path, CodeInvalidSpec, "", value, err.Error())
+		return
+	}
+	value = indirect(value)
+	if value == nil {
+		if !s.Nullable {
+			v.fail(path, CodeTypeMismatch, s.Type, value, "must not be null")
+		}
+		return
+	}
+	numErrs := len(v.errs)
+	defer func() {
+		if len(v.errs) == numErrs && len(s.Enum) > 0 {
+			v.validateEnum(s, value, path)
+		}
+	}()
 	switch s.Type {
 	case Boolean:
-		switch value.(type) {
-		case bool:
-		default:
-			return errors.New("expected boolean value")
+		if _, ok := toBool(value); !ok {
+			v.fail(path, CodeTypeMismatch, s.Type, value, "expected boolean value")
 		}
 	case String:
-		switch value.(type) {
-		case string:
-		default:
-			return errors.New("expected a string")
+		if str, ok := toString(value); ok {
+			v.validateString(s, str, path)
+		} else {
+			v.fail(path, CodeTypeMismatch, s.Type, value, "expected a string")
 		}
 	case Integer:
-		switch v := value.(type) {
-		case int, int64:
-		case float64:
-			if !(math.Floor(v) == v) {
-				return errors.New("expected an integer")
-			}
-		default:
-			return errors.New("expected an integer")
+		n, ok := toRat(value)
+		if !ok && isValidNumber(value) {
+			v.fail(path, CodeOutOfRange, s.Type, value, "number is out of the supported range")
+			return
 		}
+		if !ok || !n.IsInt() {
+			v.fail(path, CodeTypeMismatch, s.Type, value, "expected an integer")
+			return
+		}
+		v.validateNumber(s, n, value, path)
 	case Number:
-		switch value.(type) {
-		case int, int64, float64:
-		default:
-			return errors.New("expected a number")
+		// compare numbers like float64 values, so "0.1" matches a minimum of 0.1
+		f, ok := toNumber(value)
+		var n *big.Rat
+		if ok {
+			n = new(big.Rat).SetFloat64(f)
+		}
+		if n == nil && isValidNumber(value) {
+			v.fail(path, CodeOutOfRange, s.Type, value, "number is out of the supported range")
+			return
 		}
+		if n == nil {
+			v.fail(path, CodeTypeMismatch, s.Type, value, "expected a number")
+			return
+		}
+		v.validateNumber(s, n, value, path)
 	case Datetime:
-		switch value := value.(type) {
-		case time.Time:
-		case string:
-			_, err := time.Parse(time.RFC3339, value)
-			if err != nil {
-				return errors.New("expected a datetime in RFC3339 format")
+		if _, ok := value.(time.Time); ok {
+			break
+		}
+		if str, ok := toString(value); ok {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				v.fail(path, CodeInvalidFormat, s.Type, value, "expected a datetime in RFC3339 format")
 			}
-		default:
-			return errors.New("expected a datetime in RFC3339 format")
+		} else {
+			v.fail(path, CodeTypeMismatch, s.Type, value, "expected a datetime in RFC3339 format")
 		}
 	case Object:
-		object, ok := value.(map[string]any)
+		object, ok := toObject(value)
 		if !ok {
-			return errors.New("expected an object")
-		}
-		if s.Fields == nil {
-			return nil
+			v.fail(path, CodeTypeMismatch, s.Type, value, "expected an object")
+			return
 		}
-		for name, field := range s.Fields {
-			v := object[name]
-			if v == nil {
+		for _, name := range sortedKeys(s.Fields) {
+			if v.done() {
+				return
+			}
+			field := s.Fields[name]
+			value, present := object[name]
+			if !present {
 				if field.Required {
-					return fmt.Errorf("%s is required", name)
+					v.errs = append(v.errs, &ValidationError{
+						Path:     path.append(name).pointer(),
+						Code:     CodeRequired,
+						Expected: field.Type,
+						Message:  name + " is required",
+						prefix:   path.prefix(),
+					})
 				}
 			} else {
-				if err := field.Spec.Validate(v); err != nil {
-					return fmt.Errorf("%s: %v", name, err)
+				v.validate(&field.Spec, value, path.append(name))
+			}
+		}
+		if s.Values != nil {
+			for _, name := range sortedKeys(object) {
+				if v.done() {
+					return
+				}
+				if _, ok := s.Fields[name]; !ok {
+					v.validate(s.Values, object[name], path.append(name))
+				}
+			}
+		} else if s.AdditionalFields != nil && !*s.AdditionalFields {
+			for _, name := range sortedKeys(object) {
+				if v.done() {
+					return
+				}
+				if _, ok := s.Fields[name]; ok {
+					continue
 				}
+				message := fmt.Sprintf("unknown field %q", name)
+				if suggestion := nearestName(name, s.Fields); suggestion != "" {
+					message += fmt.Sprintf(", did you mean %q?", suggestion)
+				}
+				v.errs = append(v.errs, &ValidationError{
+					Path:     path.append(name).pointer(),
+					Code:     CodeUnknownField,
+					Expected: s.Type,
+					Actual:   kindOf(object[name]),
+					Message:  message,
+					prefix:   path.prefix(),
+				})
 			}
 		}
 	case Array:
-		array, ok := value.([]any)
+		array, ok := toArray(value)
 		if !ok {
-			return errors.New("expected an array")
+			v.fail(path, CodeTypeMismatch, s.Type, value, "expected an array")
+			return
 		}
 		if s.Elements == nil {
-			return nil
+			return
 		}
 		for index, element := range array {
-			if err := s.Elements.Validate(element); err != nil {
-				return fmt.Errorf("element %d: %v", index, err)
+			if v.done() {
+				return
 			}
+			v.validate(s.Elements, element, path.append(index))
 		}
 	}
-	return nil
+}
+
+// resolve returns the spec that [s] refers to if it has a Ref, or [s] itself otherwise.
+func resolve(s *Spec, definitions map[string]*Spec) (*Spec, error) {
+	resolved := s
+	// follow references to references, but not in circles
+	for i := 0; resolved.Ref != ""; i++ {
+		definition, ok := definitions[resolved.Ref]
+		if !ok || i > len(definitions) {
+			return nil, fmt.Errorf("undefined reference %q", s.Ref)
+		}
+		resolved = definition
+	}
+	if resolved == s {
+		return s, nil
+	}
+	merged := *resolved
+	merged.Nullable = merged.Nullable || s.Nullable
+	if s.Description != "" {
+		merged.Description = s.Description
+	}
+	if s.AdditionalFields != nil {
+		merged.AdditionalFields = s.AdditionalFields
+	}
+	return &merged, nil
+}
+
+// validateNumber checks the range constraints of [s] for the number [n], the exact value of
+// [value].
+func (v *validator) validateNumber(s *Spec, n *big.Rat, value any, path path) {
+	switch {
+	case s.Minimum != nil && compareNumber(n, *s.Minimum) < 0:
+		v.fail(path, CodeOutOfRange, s.Type, value, "must be at least "+formatNumber(*s.Minimum))
+	case s.Maximum != nil && compareNumber(n, *s.Maximum) > 0:
+		v.fail(path, CodeOutOfRange, s.Type, value, "must be at most "+formatNumber(*s.Maximum))
+	case s.ExclusiveMinimum != nil && compareNumber(n, *s.ExclusiveMinimum) <= 0:
+		v.fail(path, CodeOutOfRange, s.Type, value, "must be greater than "+formatNumber(*s.ExclusiveMinimum))
+	case s.ExclusiveMaximum != nil && compareNumber(n, *s.ExclusiveMaximum) >= 0:
+		v.fail(path, CodeOutOfRange, s.Type, value, "must be less than "+formatNumber(*s.ExclusiveMaximum))
+	case s.MultipleOf != nil && !isRatMultipleOf(n, *s.MultipleOf):
+		v.fail(path, CodeNotMultipleOf, s.Type, value, "must be a multiple of "+formatNumber(*s.MultipleOf))
+	}
+}
+
+// compareNumber compares [n] with the bound [f] like [big.Rat.Cmp].
+func compareNumber(n *big.Rat, f float64) int {
+	bound := new(big.Rat).SetFloat64(f)
+	switch {
+	case bound != nil:
+		return n.Cmp(bound)
+	case math.IsInf(f, 1):
+		return -1
+	case math.IsInf(f, -1):
+		return 1
+	}
+	// NaN isn't a valid bound
+	return 0
+}
+
+// isRatMultipleOf is like [isMultipleOf], but exact for integers that are multiples of integers,
+// which may be too large to be represented as float64.
+func isRatMultipleOf(n *big.Rat, m float64) bool {
+	if n.IsInt() && m == math.Trunc(m) && m != 0 && !math.IsInf(m, 0) {
+		divisor, _ := big.NewFloat(m).Int(nil)
+		return new(big.Int).Rem(n.Num(), divisor).Sign() == 0
+	}
+	f, _ := n.Float64()
+	return isMultipleOf(f, m)
+}
+
+// validateString checks the length and pattern constraints of [s] for the string [str].
+func (v *validator) validateString(s *Spec, str string, path path) {
+	length := utf8.RuneCountInString(str)
+	switch {
+	case s.MinLength != nil && length < *s.MinLength:
+		v.fail(path, CodeTooShort, s.Type, str, fmt.Sprintf("must be at least %d characters long", *s.MinLength))
+	case s.MaxLength != nil && length > *s.MaxLength:
+		v.fail(path, CodeTooLong, s.Type, str, fmt.Sprintf("must be at most %d characters long", *s.MaxLength))
+	case s.Pattern != "":
+		re, err := compilePattern(s.Pattern)
+		if err != nil {
+			v.fail(path, CodeInvalidSpec, s.Type, str, err.Error())
+		} else if !re.MatchString(str) {
+			v.fail(path, CodePatternMismatch, s.Type, str, "must match pattern "+s.Pattern)
+		}
+	}
+}
+
+// maxPatterns is the number of compiled patterns kept in [patterns]. Specs may come from other
+// services, for example through FromJSONSchema, so the cache must not grow without limit.
+const maxPatterns = 256
+
+// patterns caches compiled patterns by their source, so a pattern isn't compiled again for every
+// validated value. The fields of a spec are stored by value, so the cache can't live in the spec.
+var patterns = struct {
+	sync.Mutex
+	compiled map[string]*regexp.Regexp
+}{compiled: make(map[string]*regexp.Regexp)}
+
+// compilePattern returns the compiled regular expression for [pattern].
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	patterns.Lock()
+	re, ok := patterns.compiled[pattern]
+	patterns.Unlock()
+	if ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	patterns.Lock()
+	defer patterns.Unlock()
+	if len(patterns.compiled) >= maxPatterns {
+		// evict an arbitrary pattern, it's compiled again if it's still in use
+		for evicted := range patterns.compiled {
+			delete(patterns.compiled, evicted)
+			break
+		}
+	}
+	patterns.compiled[pattern] = re
+	return re, nil
+}
+
+// validateEnum checks that [value] is one of the values in the enum of [s].
+func (v *validator) validateEnum(s *Spec, value any, path path) {
+	for _, allowed := range s.Enum {
+		if equalValues(s.Type, value, allowed) {
+			return
+		}
+	}
+	formatted := make([]string, len(s.Enum))
+	for i, allowed := range s.Enum {
+		data, err := json.Marshal(allowed)
+		if err != nil {
+			formatted[i] = fmt.Sprint(allowed)
+		} else {
+			formatted[i] = string(data)
+		}
+	}
+	v.fail(path, CodeNotInEnum, s.Type, value, "must be one of "+strings.Join(formatted, ", "))
+}
+
+// equalValues returns true if [a] and [b] represent the same value of type [typ]. Numbers are
+// compared by value regardless of their Go type, and datetimes are compared as points in time.
+func equalValues(typ Type, a, b any) bool {
+	switch typ {
+	case Integer:
+		x, ok1 := toRat(a)
+		y, ok2 := toRat(b)
+		return ok1 && ok2 && x.Cmp(y) == 0
+	case Number:
+		x, ok1 := toNumber(a)
+		y, ok2 := toNumber(b)
+		return ok1 && ok2 && x == y
+	case Datetime:
+		x, ok1 := toTime(a)
+		y, ok2 := toTime(b)
+		return ok1 && ok2 && x.Equal(y)
+	case String:
+		x, ok1 := toString(a)
+		y, ok2 := toString(b)
+		return ok1 && ok2 && x == y
+	case Boolean:
+		x, ok1 := toBool(a)
+		y, ok2 := toBool(b)
+		return ok1 && ok2 && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// The following helpers convert the Go values accepted by Validate and Load to the types used by
+// encoding/json, so documents can be built by hand with types like int32, []string or json.Number.
+
+// indirect returns the value [value] points to, or nil for nil pointers.
+func indirect(value any) any {
+	switch value.(type) {
+	case nil, bool, string, float64, map[string]any, []any:
+		return value
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Pointer {
+		return value
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+// toNumber returns the value of [value] if it's a number of any Go numeric kind or a
+// json.Number.
+func toNumber(value any) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case int:
+		return float64(value), true
+	case json.Number:
+		if !isJSONNumber(string(value)) {
+			return 0, false
+		}
+		n, err := value.Float64()
+		return n, err == nil
+	case big.Int:
+		n, _ := new(big.Float).SetInt(&value).Float64()
+		return n, true
+	}
+	v := reflect.ValueOf(value)
+	switch {
+	case v.CanInt():
+		return float64(v.Int()), true
+	case v.CanUint():
+		return float64(v.Uint()), true
+	case v.CanFloat():
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// maxExponent limits the exponent of json.Number values converted to exact numbers, since the
+// memory needed for 1e1000000000 grows with the exponent. Larger exponents are reported as out of
+// range.
+const maxExponent = 1000
+
+// toRat returns the exact value of [value] if it's a number of any Go numeric kind, a json.Number
+// or a big.Int.
+func toRat(value any) (*big.Rat, bool) {
+	switch value := value.(type) {
+	case json.Number:
+		if !isJSONNumber(string(value)) {
+			return nil, false
+		}
+		if i := strings.IndexAny(string(value), "eE"); i >= 0 {
+			exponent, err := strconv.Atoi(string(value[i+1:]))
+			if err != nil || exponent > maxExponent || exponent < -maxExponent {
+				return nil, false
+			}
+		}
+		return new(big.Rat).SetString(string(value))
+	case big.Int:
+		return new(big.Rat).SetInt(&value), true
+	}
+	v := reflect.ValueOf(value)
+	switch {
+	case v.CanInt():
+		return new(big.Rat).SetInt64(v.Int()), true
+	case v.CanUint():
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(v.Uint())), true
+	case v.CanFloat():
+		n := new(big.Rat).SetFloat64(v.Float())
+		return n, n != nil
+	}
+	return nil, false
+}
+
+// isJSONNumber returns true if [s] is a number in the syntax of JSON, which unlike the parsers of
+// strconv and math/big doesn't allow hexadecimal numbers or fractions like "4/2".
+func isJSONNumber(s string) bool {
+	i := 0
+	digits := func() int {
+		start := i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i - start
+	}
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	if n := digits(); n == 0 || (n > 1 && s[i-n] == '0') {
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		if digits() == 0 {
+			return false
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			return false
+		}
+	}
+	return i == len(s)
+}
+
+// isValidNumber returns true if [value] is a json.Number in valid syntax, so a failed conversion
+// means that it is out of range.
+func isValidNumber(value any) bool {
+	number, ok := value.(json.Number)
+	return ok && isJSONNumber(string(number))
+}
+
+// toBool returns the value of [value] if it's a bool, including named bool types.
+func toBool(value any) (bool, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Bool {
+		return false, false
+	}
+	return v.Bool(), true
+}
+
+// toString returns the value of [value] if it's a string, including named string types but not
+// json.Number.
+func toString(value any) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return "", false
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.String {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// toObject returns [value] as a map[string]any if it's a map with string keys.
+func toObject(value any) (map[string]any, bool) {
+	if object, ok := value.(map[string]any); ok {
+		return object, true
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	object := make(map[string]any, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		object[iter.Key().String()] = iter.Value().Interface()
+	}
+	return object, true
+}
+
+// toArray returns [value] as a []any if it's a slice or an array.
+func toArray(value any) ([]any, bool) {
+	if array, ok := value.([]any); ok {
+		return array, true
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	array := make([]any, v.Len())
+	for i := range array {
+		array[i] = v.Index(i).Interface()
+	}
+	return array, true
+}
+
+// toTime returns the value of [value] if it's a time or a string in RFC3339 format.
+func toTime(value any) (time.Time, bool) {
+	if t, ok := value.(time.Time); ok {
+		return t, true
+	}
+	str, ok := toString(value)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, str)
+	return t, err == nil
+}
+
+// isMultipleOf returns true if [n] is a multiple of [m], allowing for floating point rounding
+// errors, so that 0.3 is a multiple of 0.1.
+func isMultipleOf(n, m float64) bool {
+	q := n / m
+	return math.Abs(q-math.Round(q)) < 1e-9
+}
+
+// formatNumber formats a number for error messages, without exponents.
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// nearestName returns the field name in [fields] that is most similar to [name], or "" if none of
+// them is similar enough to be a likely typo.
+func nearestName(name string, fields map[string]Field) string {
+	best, bestDistance := "", len(name)/3+1
+	for _, candidate := range sortedKeys(fields) {
+		if d := editDistance(name, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between [a] and [b].
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// sortedKeys returns the keys of [m] in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -2,6 +2,10 @@ package jsonspec
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
 	"testing"
 	"time"
 
@@ -11,8 +15,15 @@ import (
 func TestSpecAsJSON(t *testing.T) {
 	// marshal and unmarshal a Spec to make sure it works with encoding/json
 	spec := &Spec{
-		Description: "Object describing a person",
+		Description:      "Object describing a person",
+		AdditionalFields: new(bool),
 		Fields: map[string]Field{
+			"age": {
+				Spec: Spec{
+					Type:    Integer,
+					Minimum: new(float64),
+				},
+			},
 			"first_name": {
 				Spec: Spec{
 					Type:        String,
@@ -58,7 +69,17 @@ func TestSpecValidateSuccess(t *testing.T) {
 		{0, 4.0},
 		{0.0, 4},
 		{0.0, 4.1},
+		{0, int32(4)},
+		{0, uint8(4)},
+		{0, json.Number("4")},
+		{0.0, json.Number("4.1")},
+		{0.0, float32(4.1)},
+		{"", Region("us")},
+		{Region(""), "us"},
 		{time.Time{}, "2024-03-06T12:30:23Z"},
+		{[]string{}, []string{"a", "b"}},
+		{[]*int{}, []*int{nil, ptr(1)}},
+		{map[string]int{}, map[string]int64{"a": 1}},
 		{
 			struct{ FirstName string }{},
 			map[string]any{"first_name": "Jane"},
@@ -84,6 +105,56 @@ func TestSpecValidateSuccess(t *testing.T) {
 				map[string]any{"last_name": "Doe"},
 			},
 		},
+		{
+			struct {
+				Port  int     `min:"1" max:"65535"`
+				Price float64 `exclusive_min:"0" multiple_of:"0.01"`
+			}{},
+			map[string]any{"port": 443, "price": 19.99},
+		},
+		{
+			struct {
+				Region  string `pattern:"^[a-z]{2}-\\d$"`
+				Company string `min_length:"2" max_length:"4"`
+			}{},
+			map[string]any{"region": "eu-1", "company": "Çãõ"},
+		},
+		{
+			struct {
+				Region   Region
+				Level    int       `enum:"1,2,3"`
+				Deadline time.Time `enum:"2024-03-07T11:38:47Z"`
+			}{},
+			map[string]any{"region": "eu", "level": 2.0, "deadline": "2024-03-07T08:38:47-03:00"},
+		},
+		{
+			map[string][]int{},
+			map[string]any{"a": []any{1, 2}, "b": []any{}},
+		},
+		{
+			Node{},
+			map[string]any{
+				"name": "root",
+				"children": []any{
+					map[string]any{"name": "a", "children": []any{map[string]any{"name": "a.1"}}},
+				},
+				"parent": nil,
+			},
+		},
+		{
+			struct {
+				Nickname *string `required:"true"`
+				Manager  *struct{ Name string }
+				Scores   []*int
+			}{},
+			map[string]any{"nickname": nil, "manager": nil, "scores": []any{1, nil}},
+		},
+		{
+			struct {
+				Nickname *string
+			}{},
+			map[string]any{"nickname": "JD"},
+		},
 		{
 			NestedStruct{},
 			map[string]any{
@@ -161,6 +232,17 @@ func TestSpecValidateError(t *testing.T) {
 		{123, 123.456, "expected an integer"},
 		{0.0, "hello", "expected a number"},
 		{time.Time{}, "hello", "expected a datetime in RFC3339 format"},
+		{"", json.Number("1"), "expected a string"},
+		{123, json.Number("1.5"), "expected an integer"},
+		{123, json.Number("4/2"), "expected an integer"},
+		{123, json.Number("0x10"), "expected an integer"},
+		{123, json.Number("1_000"), "expected an integer"},
+		{123, json.Number("01"), "expected an integer"},
+		{0.0, json.Number("0x1p4"), "expected a number"},
+		{0.0, json.Number("Inf"), "expected a number"},
+		{0.0, json.Number(".5"), "expected a number"},
+		{[]int{}, []string{"a"}, "element 0: expected an integer"},
+		{map[string]int{}, map[string]string{"a": "b"}, "a: expected an integer"},
 		{
 			struct{ FirstName string }{},
 			"hello",
@@ -211,6 +293,143 @@ func TestSpecValidateError(t *testing.T) {
 			},
 			"customers: element 0: contact_details: phone_numbers: element 0: number is required",
 		},
+		{
+			struct {
+				Intercom struct {
+					AccessToken string
+				} `additional_fields:"false"`
+			}{},
+			map[string]any{"intercom": map[string]any{"acess_token": "abc"}},
+			`intercom: unknown field "acess_token", did you mean "access_token"?`,
+		},
+		{
+			struct {
+				Intercom struct {
+					AccessToken string
+				} `additional_fields:"false"`
+			}{},
+			map[string]any{"intercom": map[string]any{"workspace": "abc"}},
+			`intercom: unknown field "workspace"`,
+		},
+		{
+			struct {
+				Port int `min:"1" max:"65535"`
+			}{},
+			map[string]any{"port": 0},
+			"port: must be at least 1",
+		},
+		{
+			struct {
+				Port int `min:"1" max:"65535"`
+			}{},
+			map[string]any{"port": 65536.0},
+			"port: must be at most 65535",
+		},
+		{
+			struct {
+				Ratio float64 `exclusive_min:"0" exclusive_max:"1"`
+			}{},
+			map[string]any{"ratio": 1.0},
+			"ratio: must be less than 1",
+		},
+		{
+			struct {
+				BatchSize int `exclusive_min:"0" multiple_of:"10"`
+			}{},
+			map[string]any{"batch_size": 0},
+			"batch_size: must be greater than 0",
+		},
+		{
+			struct {
+				BatchSize int `exclusive_min:"0" multiple_of:"10"`
+			}{},
+			map[string]any{"batch_size": 25},
+			"batch_size: must be a multiple of 10",
+		},
+		{
+			struct {
+				Subdomain string `min_length:"3" max_length:"8" pattern:"^[a-z]+$"`
+			}{},
+			map[string]any{"subdomain": "ab"},
+			"subdomain: must be at least 3 characters long",
+		},
+		{
+			struct {
+				Subdomain string `min_length:"3" max_length:"8" pattern:"^[a-z]+$"`
+			}{},
+			map[string]any{"subdomain": "abcdefghi"},
+			"subdomain: must be at most 8 characters long",
+		},
+		{
+			struct {
+				Subdomain string `min_length:"3" max_length:"8" pattern:"^[a-z]+$"`
+			}{},
+			map[string]any{"subdomain": "ab-cd"},
+			"subdomain: must match pattern ^[a-z]+$",
+		},
+		{
+			struct {
+				Region string `enum:"us,eu,ap"`
+			}{},
+			map[string]any{"region": "sa"},
+			`region: must be one of "us", "eu", "ap"`,
+		},
+		{
+			struct{ Region Region }{},
+			map[string]any{"region": "US"},
+			`region: must be one of "us", "eu", "ap"`,
+		},
+		{
+			struct {
+				Level int `enum:"1,2,3"`
+			}{},
+			map[string]any{"level": 4.0},
+			"level: must be one of 1, 2, 3",
+		},
+		{
+			struct{ Limits map[string]int }{},
+			map[string]any{"limits": map[string]any{"a": 1, "b": "oops"}},
+			"limits: b: expected an integer",
+		},
+		{
+			map[string]struct {
+				Name string `required:"true"`
+			}{},
+			map[string]any{"jane": map[string]any{"name": "Jane"}, "joe": map[string]any{}},
+			"joe: name is required",
+		},
+		{
+			struct {
+				FirstName string `required:"true"`
+			}{},
+			map[string]any{"first_name": nil},
+			"first_name: must not be null",
+		},
+		{
+			struct{ FirstName string }{},
+			map[string]any{"first_name": nil},
+			"first_name: must not be null",
+		},
+		{
+			struct {
+				Nickname *string `required:"true"`
+			}{},
+			map[string]any{},
+			"nickname is required",
+		},
+		{
+			[]int{},
+			[]any{1, nil},
+			"element 1: must not be null",
+		},
+		{
+			Node{},
+			map[string]any{
+				"name":     "root",
+				"children": []any{map[string]any{"name": "a"}, map[string]any{"children": []any{}}},
+			},
+			"children: element 1: name is required",
+		},
 	}
 	for _, c := range cases {
 		spec, err := For(c.o)
@@ -228,3 +447,185 @@ func TestSpecValidateError(t *testing.T) {
 		}
 	}
 }
+
+func TestSpecValidateAll(t *testing.T) {
+	spec, err := For(struct {
+		ID        int    `required:"true"`
+		FirstName string `required:"true"`
+		LastName  string
+		Emails    []string
+	}{})
+	if err != nil {
+		t.Fatalf("For returned error: %v", err)
+	}
+	value := map[string]any{
+		"last_name": 123,
+		"emails":    []any{"jane@example.com", 1, false},
+	}
+	want := "emails: element 1: expected a string\n" +
+		"emails: element 2: expected a string\n" +
+		"first_name is required\n" +
+		"id is required\n" +
+		"last_name: expected a string"
+	// run several times because map iteration order is random
+	for i := 0; i < 10; i++ {
+		err := spec.ValidateAll(value)
+		if err == nil {
+			t.Fatalf("spec.ValidateAll(%v) did not return error", value)
+		}
+		if got := err.Error(); got != want {
+			t.Fatalf("spec.ValidateAll(%v) returned %q, want %q", value, got, want)
+		}
+	}
+	if err := spec.ValidateAll(map[string]any{"id": 1, "first_name": "Jane"}); err != nil {
+		t.Errorf("spec.ValidateAll returned error for valid value: %v", err)
+	}
+}
+
+func TestSpecValidateUndefinedReference(t *testing.T) {
+	spec := &Spec{Type: Array, Elements: &Spec{Type: Object, Ref: "Node"}}
+	err := spec.Validate([]any{map[string]any{}})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Code != CodeInvalidSpec {
+		t.Errorf("spec.Validate returned %v, want an error with code %s", err, CodeInvalidSpec)
+	}
+}
+
+func TestSpecFieldSpec(t *testing.T) {
+	type Addr struct {
+		Street string `required:"true"`
+	}
+	type Person struct {
+		Home Addr
+		Work Addr
+	}
+	spec, err := For(Person{})
+	if err != nil {
+		t.Fatalf("For returned error: %v", err)
+	}
+	home := spec.FieldSpec("home")
+	if home == nil {
+		t.Fatal("FieldSpec(\"home\") returned nil")
+	}
+	if err := home.Validate(map[string]any{"street": "Main St"}); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+	err = home.Validate(map[string]any{})
+	if want := "street is required"; err == nil || err.Error() != want {
+		t.Errorf("Validate returned %v, want %q", err, want)
+	}
+	if street := home.FieldSpec("street"); street == nil || street.Type != String {
+		t.Errorf("FieldSpec(\"street\") returned %v, want a string spec", street)
+	}
+	if missing := spec.FieldSpec("missing"); missing != nil {
+		t.Errorf("FieldSpec(\"missing\") returned %v, want nil", missing)
+	}
+}
+
+func TestSpecWithDefinitionsAsJSON(t *testing.T) {
+	spec, err := For(Node{})
+	if err != nil {
+		t.Fatalf("For returned error: %v", err)
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+	want := `{"type":"object","ref":"Node","definitions":{"Node":{"type":"object","fields":{` +
+		`"children":{"type":"array","elements":{"type":"object","ref":"Node"}},` +
+		`"name":{"type":"string","required":true},` +
+		`"parent":{"type":"object","nullable":true,"ref":"Node"}}}}}`
+	if got := string(data); got != want {
+		t.Errorf("json.Marshal(spec) == %s, want %s", got, want)
+	}
+}
+
+func TestSpecValidateJSONPrecise(t *testing.T) {
+	cases := []struct {
+		spec  *Spec
+		input string
+		want  string
+	}{
+		{&Spec{Type: Integer}, `9007199254740993`, ""},
+		{&Spec{Type: Integer}, `123456789012345678901234567890`, ""},
+		{&Spec{Type: Integer}, `1.5e3`, ""},
+		{&Spec{Type: Number, Minimum: ptr(0.1)}, `0.1`, ""},
+		{&Spec{Type: Integer}, `9007199254740993.5`, "expected an integer"},
+		{&Spec{Type: Integer, Maximum: ptr(9007199254740992.0)}, `9007199254740993`, "must be at most 9007199254740992"},
+		{&Spec{Type: Integer, MultipleOf: ptr(2.0)}, `9007199254740993`, "must be a multiple of 2"},
+		{&Spec{Type: Integer}, `1e1001`, "number is out of the supported range"},
+		{&Spec{Type: Integer}, `1e-1001`, "number is out of the supported range"},
+		{&Spec{Type: Number}, `1e400`, "number is out of the supported range"},
+		{&Spec{Type: Integer}, `1 2`, "invalid character '2' after top-level value"},
+		{&Spec{Type: Integer}, ``, "unexpected end of JSON input"},
+	}
+	for _, c := range cases {
+		err := c.spec.ValidateJSON([]byte(c.input))
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != c.want {
+			t.Errorf("ValidateJSON(%s) returned %q, want %q", c.input, got, c.want)
+		}
+	}
+
+	var validationErr *ValidationError
+	err := (&Spec{Type: Integer}).ValidateJSON([]byte(`1e2000`))
+	if !errors.As(err, &validationErr) || validationErr.Code != CodeOutOfRange {
+		t.Errorf("ValidateJSON(1e2000) returned %v, want an error with code %s", err, CodeOutOfRange)
+	}
+
+	spec, err := For(big.Int{})
+	if err != nil {
+		t.Fatalf("For returned error: %v", err)
+	}
+	if diff := cmp.Diff(&Spec{Type: Integer}, spec); diff != "" {
+		t.Errorf("For(big.Int{}) mismatch (-want +got):\n%s", diff)
+	}
+	if err := spec.Validate(big.NewInt(1)); err != nil {
+		t.Errorf("Validate returned error for a *big.Int: %v", err)
+	}
+}
+
+func TestSpecValidateJSONSyntaxError(t *testing.T) {
+	// syntax errors are reported like by json.Unmarshal, with their offset
+	cases := []struct {
+		input  string
+		offset int64
+	}{
+		{`{"a": }`, 7},
+		{`{} garbage`, 4},
+		{`{} {}`, 4},
+	}
+	for _, c := range cases {
+		err := (&Spec{Type: Object}).ValidateJSON([]byte(c.input))
+		var syntaxErr *json.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("ValidateJSON(%s) returned %v, want a *json.SyntaxError", c.input, err)
+			continue
+		}
+		if syntaxErr.Offset != c.offset {
+			t.Errorf("ValidateJSON(%s) returned offset %d, want %d", c.input, syntaxErr.Offset, c.offset)
+		}
+	}
+}
+
+func TestCompilePatternBounded(t *testing.T) {
+	for i := 0; i < maxPatterns+10; i++ {
+		pattern := fmt.Sprintf("^%d$", i)
+		re, err := compilePattern(pattern)
+		if err != nil {
+			t.Fatalf("compilePattern(%q) returned error: %v", pattern, err)
+		}
+		if !re.MatchString(strconv.Itoa(i)) {
+			t.Errorf("compilePattern(%q) doesn't match %d", pattern, i)
+		}
+	}
+	patterns.Lock()
+	n := len(patterns.compiled)
+	patterns.Unlock()
+	if n > maxPatterns {
+		t.Errorf("%d patterns cached, want at most %d", n, maxPatterns)
+	}
+}
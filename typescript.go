@@ -0,0 +1,254 @@
+package jsonspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// TypeScript generates TypeScript declarations for [specs], keyed by the name of the type to
+// declare. The definitions of the specs are declared too, so their names must not clash with other
+// declarations unless they describe the same type.
+//
+// Objects with fields become interfaces, with a property for each field that is optional unless
+// the field is required. Arrays become T[], nullable values T | null, and enums unions of literal
+// types. Datetimes become strings, with a note in the JSDoc comment of the field or type, which is
+// also added for arrays and maps of datetimes. Descriptions and defaults are kept in JSDoc comments.
+func TypeScript(specs map[string]*Spec) (string, error) {
+	declarations := make(map[string]*Spec)
+	definitions := make(map[string]*Spec)
+	for _, name := range sortedKeys(specs) {
+		spec := specs[name]
+		for _, defName := range sortedKeys(spec.Definitions) {
+			definition := spec.Definitions[defName]
+			if existing, ok := definitions[defName]; ok && !reflect.DeepEqual(existing, definition) {
+				return "", fmt.Errorf("conflicting definitions named %s", defName)
+			}
+			definitions[defName] = definition
+		}
+	}
+	for name, spec := range definitions {
+		declarations[name] = spec
+	}
+	for name, spec := range specs {
+		if spec.Ref == name {
+			// the spec is the definition itself
+			continue
+		}
+		if _, ok := declarations[name]; ok {
+			return "", fmt.Errorf("conflicting declarations named %s", name)
+		}
+		declarations[name] = spec
+	}
+
+	w := &tsWriter{definitions: definitions}
+	for i, name := range sortedKeys(declarations) {
+		if !tsIdentifierRe.MatchString(name) {
+			return "", fmt.Errorf("invalid TypeScript identifier %q", name)
+		}
+		if i > 0 {
+			w.b.WriteString("\n")
+		}
+		if err := w.declaration(name, declarations[name]); err != nil {
+			return "", fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return w.b.String(), nil
+}
+
+var tsIdentifierRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// A tsWriter writes TypeScript declarations.
+type tsWriter struct {
+	b           strings.Builder
+	definitions map[string]*Spec
+}
+
+// declaration writes the declaration of the type [name].
+func (w *tsWriter) declaration(name string, spec *Spec) error {
+	w.comment(spec, spec.Description, nil, "")
+	if spec.Type == Object && spec.Ref == "" && spec.Values == nil && len(spec.Fields) > 0 && !spec.Nullable {
+		fmt.Fprintf(&w.b, "export interface %s ", name)
+		if err := w.fields(spec, ""); err != nil {
+			return err
+		}
+		w.b.WriteString("\n")
+		return nil
+	}
+	fmt.Fprintf(&w.b, "export type %s = ", name)
+	if err := w.typ(spec, ""); err != nil {
+		return err
+	}
+	w.b.WriteString(";\n")
+	return nil
+}
+
+// comment writes a JSDoc comment with the description of a value, its default and a note for
+// datetimes, if there is anything to say.
+func (w *tsWriter) comment(spec *Spec, description string, defaultValue any, indent string) {
+	var lines []string
+	if description != "" {
+		lines = append(lines, strings.Split(description, "\n")...)
+	}
+	if note := datetimeNote(spec); note != "" {
+		lines = append(lines, note)
+	}
+	if defaultValue != nil {
+		data, err := json.Marshal(schemaValue(defaultValue))
+		if err == nil {
+			lines = append(lines, "@default "+string(data))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	if len(lines) == 1 {
+		fmt.Fprintf(&w.b, "%s/** %s */\n", indent, escapeComment(lines[0]))
+		return
+	}
+	fmt.Fprintf(&w.b, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(&w.b, "%s * %s\n", indent, escapeComment(line))
+	}
+	fmt.Fprintf(&w.b, "%s */\n", indent)
+}
+
+// datetimeNote returns the note for a datetime, or for an array or map whose elements or values
+// are or contain datetimes, which TypeScript only knows as strings.
+func datetimeNote(spec *Spec) string {
+	if spec.Type == Datetime {
+		return "Datetime in RFC 3339 format."
+	}
+	var inner *Spec
+	var what string
+	switch spec.Type {
+	case Array:
+		inner, what = spec.Elements, "Elements"
+	case Object:
+		inner, what = spec.Values, "Values"
+	}
+	if inner == nil || inner.Ref != "" {
+		return ""
+	}
+	if inner.Type == Datetime {
+		return what + " are datetimes in RFC 3339 format."
+	}
+	if datetimeNote(inner) != "" {
+		return what + " contain datetimes in RFC 3339 format."
+	}
+	return ""
+}
+
+// escapeComment makes sure a line doesn't end the comment it is part of.
+func escapeComment(line string) string {
+	return strings.ReplaceAll(line, "*/", "*\\/")
+}
+
+// typ writes the type expression for [spec].
+func (w *tsWriter) typ(spec *Spec, indent string) error {
+	if err := w.nonNullType(spec, indent); err != nil {
+		return err
+	}
+	if spec.Nullable {
+		w.b.WriteString(" | null")
+	}
+	return nil
+}
+
+func (w *tsWriter) nonNullType(spec *Spec, indent string) error {
+	if spec.Ref != "" {
+		if _, ok := w.definitions[spec.Ref]; !ok {
+			return fmt.Errorf("undefined reference %q", spec.Ref)
+		}
+		w.b.WriteString(spec.Ref)
+		return nil
+	}
+	if len(spec.Enum) > 0 {
+		for i, value := range spec.Enum {
+			if i > 0 {
+				w.b.WriteString(" | ")
+			}
+			data, err := json.Marshal(schemaValue(value))
+			if err != nil {
+				return err
+			}
+			w.b.Write(data)
+		}
+		return nil
+	}
+	switch spec.Type {
+	case Boolean:
+		w.b.WriteString("boolean")
+	case String, Datetime:
+		w.b.WriteString("string")
+	case Integer, Number:
+		w.b.WriteString("number")
+	case Array:
+		if spec.Elements == nil {
+			w.b.WriteString("unknown[]")
+			return nil
+		}
+		parens := spec.Elements.Nullable || (len(spec.Elements.Enum) > 1 && spec.Elements.Ref == "") ||
+			(spec.Elements.Type == Object && spec.Elements.Ref == "" && spec.Elements.Values != nil && len(spec.Elements.Fields) > 0)
+		if parens {
+			w.b.WriteString("(")
+		}
+		if err := w.typ(spec.Elements, indent); err != nil {
+			return err
+		}
+		if parens {
+			w.b.WriteString(")")
+		}
+		w.b.WriteString("[]")
+	case Object:
+		if len(spec.Fields) > 0 {
+			if err := w.fields(spec, indent); err != nil {
+				return err
+			}
+		}
+		switch {
+		case spec.Values != nil:
+			if len(spec.Fields) > 0 {
+				w.b.WriteString(" & ")
+			}
+			w.b.WriteString("Record<string, ")
+			if err := w.typ(spec.Values, indent); err != nil {
+				return err
+			}
+			w.b.WriteString(">")
+		case len(spec.Fields) == 0:
+			w.b.WriteString("Record<string, unknown>")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", spec.Type)
+	}
+	return nil
+}
+
+// fields writes an object type with the fields of [spec].
+func (w *tsWriter) fields(spec *Spec, indent string) error {
+	w.b.WriteString("{\n")
+	inner := indent + "  "
+	for _, name := range sortedKeys(spec.Fields) {
+		field := spec.Fields[name]
+		w.comment(&field.Spec, field.Description, field.Default, inner)
+		key := name
+		if !tsIdentifierRe.MatchString(name) {
+			data, _ := json.Marshal(name)
+			key = string(data)
+		}
+		optional := "?"
+		if field.Required {
+			optional = ""
+		}
+		fmt.Fprintf(&w.b, "%s%s%s: ", inner, key, optional)
+		if err := w.typ(&field.Spec, inner); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		w.b.WriteString(";\n")
+	}
+	w.b.WriteString(indent + "}")
+	return nil
+}
@@ -0,0 +1,99 @@
+package jsonspec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTypeScript(t *testing.T) {
+	type Person struct {
+		ID        int    `description:"Unique ID" required:"true"`
+		FirstName string `default:"Jane"`
+		Password  string `required:"true" tags:"secret"`
+		Region    Region
+		Birthday  time.Time
+		Logins    []time.Time
+		Holidays  map[string]time.Time
+		Shifts    [][]time.Time
+		Nickname  *string
+		Scores    []*float64
+		Labels    map[string]string
+		Extra     map[string]any
+		Manager   *Node
+	}
+	personSpec, err := For(Person{})
+	if err != nil {
+		t.Fatalf("For returned error: %v", err)
+	}
+	nodeSpec, err := For(Node{})
+	if err != nil {
+		t.Fatalf("For returned error: %v", err)
+	}
+	specs := map[string]*Spec{
+		"Person": personSpec,
+		"Node":   nodeSpec,
+		"Tags":   {Type: Array, Elements: &Spec{Type: String, Enum: []any{"a", "b"}}},
+		"Weird":  {Type: Object, Description: "Keys that aren't identifiers", Fields: map[string]Field{"first-name": {Spec: Spec{Type: String}}}},
+	}
+	want := `export interface Node {
+  children?: Node[];
+  name: string;
+  parent?: Node | null;
+}
+
+export interface Person {
+  /** Datetime in RFC 3339 format. */
+  birthday?: string;
+  extra?: Record<string, unknown>;
+  /** @default "Jane" */
+  first_name?: string;
+  /** Values are datetimes in RFC 3339 format. */
+  holidays?: Record<string, string>;
+  /** Unique ID */
+  id: number;
+  labels?: Record<string, string>;
+  /** Elements are datetimes in RFC 3339 format. */
+  logins?: string[];
+  manager?: Node | null;
+  nickname?: string | null;
+  password: string;
+  region?: "us" | "eu" | "ap";
+  scores?: (number | null)[];
+  /** Elements contain datetimes in RFC 3339 format. */
+  shifts?: string[][];
+}
+
+export type Tags = ("a" | "b")[];
+
+/** Keys that aren't identifiers */
+export interface Weird {
+  "first-name"?: string;
+}
+`
+	got, err := TypeScript(specs)
+	if err != nil {
+		t.Fatalf("TypeScript returned error: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("TypeScript result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTypeScriptError(t *testing.T) {
+	cases := []map[string]*Spec{
+		{"not-an-identifier": {Type: String}},
+		{"Broken": {Type: Array, Elements: &Spec{Type: Object, Ref: "Missing"}}},
+		{
+			"A": {Type: Object, Ref: "X", Definitions: map[string]*Spec{"X": {Type: String}}},
+			"B": {Type: Object, Ref: "X", Definitions: map[string]*Spec{"X": {Type: Integer}}},
+		},
+	}
+	for _, specs := range cases {
+		_, err := TypeScript(specs)
+		if err == nil {
+			t.Errorf("TypeScript(%v) did not return error", specs)
+		}
+	}
+}